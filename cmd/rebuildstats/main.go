@@ -0,0 +1,24 @@
+// Утилита полной пересборки агрегатов статистики (stats_*) по всем завершённым играм.
+// Использование: go run ./cmd/rebuildstats (подключение — LOCAL_DSN или DATABASE_URL, как у сервера)
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/handlers"
+)
+
+func main() {
+	if err := database.InitDB(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка БД: %v\n", err)
+		os.Exit(1)
+	}
+	games, err := handlers.RebuildStatsAggregates(database.GetDB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Агрегаты статистики пересобраны: %d игр\n", games)
+}
@@ -1,5 +1,5 @@
 // Package database — инициализация подключения к PostgreSQL через GORM.
-// Настраивает пул (MaxIdleConns=5, MaxOpenConns=20) и выполняет AutoMigrate для User, Deck, Game, GamePlayer, GameTurn, AppSetting.
+// Настраивает пул (MaxIdleConns=5, MaxOpenConns=20) и выполняет AutoMigrate для User, Deck, Game, GamePlayer, GameTurn, GamePause, AppSetting, рейтингов, агрегатов статистики, достижений, сезонов, турниров, матчей и снимков таблицы лидеров.
 package database
 
 import (
@@ -54,7 +54,12 @@ func InitDB() error {
 	sqlDB.SetConnMaxLifetime(time.Duration(connMaxLifetimeMinutes) * time.Minute)
 	sqlDB.SetConnMaxIdleTime(time.Duration(connMaxIdleTimeMinutes) * time.Minute)
 
-	if err := DB.AutoMigrate(&models.User{}, &models.Deck{}, &models.Game{}, &models.GamePlayer{}, &models.GameTurn{}, &models.AppSetting{}); err != nil {
+	if err := DB.AutoMigrate(&models.User{}, &models.Deck{}, &models.Game{}, &models.GamePlayer{}, &models.GameTurn{}, &models.GamePause{}, &models.AppSetting{},
+		&models.PlayerRating{}, &models.PlayerRatingHistory{}, &models.DeckRating{},
+		&models.StatsPlayerAggregate{}, &models.StatsPlayerDeckAggregate{}, &models.StatsDeckAggregate{}, &models.StatsDeckMatchupAggregate{},
+		&models.StatsPeriodAggregate{}, &models.StatsPeriodDeckAggregate{}, &models.UserAchievement{},
+		&models.Season{}, &models.SeasonStanding{}, &models.Tournament{}, &models.TournamentPlayer{}, &models.TournamentMatch{}, &models.Match{},
+		&models.LeaderboardSnapshot{}, &models.LeaderboardEntry{}); err != nil {
 		return fmt.Errorf("миграции: %w", err)
 	}
 
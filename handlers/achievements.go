@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// achievementProgress — накопленная история игрока, по которой проверяются правила достижений.
+type achievementProgress struct {
+	Games            int
+	Wins             int
+	CurrentWinStreak int
+	WinDecks         map[int]bool
+	Beaten           map[uint]bool
+}
+
+// achievementContext — состояние на момент сразу после партии Game для игрока UserID.
+// KnownPlayers — все, кто сыграл хотя бы одну завершённую партию к этому моменту (включая эту).
+type achievementContext struct {
+	UserID       uint
+	Game         models.Game
+	Won          bool
+	DurationSec  int
+	Progress     *achievementProgress
+	KnownPlayers map[uint]bool
+}
+
+// achievementRule — декларативное правило достижения: открывается после первой партии, на которой Check вернул true.
+type achievementRule interface {
+	Code() string
+	Title() string
+	Description() string
+	Check(ctx achievementContext) bool
+}
+
+// firstWinRule — первая победа.
+type firstWinRule struct{}
+
+func (firstWinRule) Code() string        { return "first_win" }
+func (firstWinRule) Title() string       { return "Первая победа" }
+func (firstWinRule) Description() string { return "Выиграть первую партию" }
+func (firstWinRule) Check(ctx achievementContext) bool {
+	return ctx.Progress.Wins >= 1
+}
+
+// winStreakRule — серия побед подряд.
+type winStreakRule struct{ length int }
+
+func (r winStreakRule) Code() string  { return fmt.Sprintf("win_streak_%d", r.length) }
+func (r winStreakRule) Title() string { return fmt.Sprintf("Серия из %d побед", r.length) }
+func (r winStreakRule) Description() string {
+	return fmt.Sprintf("Выиграть %d партий подряд", r.length)
+}
+func (r winStreakRule) Check(ctx achievementContext) bool {
+	return ctx.Progress.CurrentWinStreak >= r.length
+}
+
+// deckVarietyRule — победы на разных колодах.
+type deckVarietyRule struct{ decks int }
+
+func (r deckVarietyRule) Code() string  { return fmt.Sprintf("win_decks_%d", r.decks) }
+func (r deckVarietyRule) Title() string { return "Мастер на все руки" }
+func (r deckVarietyRule) Description() string {
+	return fmt.Sprintf("Побеждать на %d разных колодах", r.decks)
+}
+func (r deckVarietyRule) Check(ctx achievementContext) bool {
+	return len(ctx.Progress.WinDecks) >= r.decks
+}
+
+// fastWinRule — победа в партии короче maxDuration (без пауз).
+type fastWinRule struct{ maxDuration time.Duration }
+
+func (r fastWinRule) Code() string  { return fmt.Sprintf("fast_win_%dm", int(r.maxDuration.Minutes())) }
+func (r fastWinRule) Title() string { return "Блицкриг" }
+func (r fastWinRule) Description() string {
+	return fmt.Sprintf("Выиграть партию быстрее %d минут", int(r.maxDuration.Minutes()))
+}
+func (r fastWinRule) Check(ctx achievementContext) bool {
+	return ctx.Won && ctx.DurationSec > 0 && ctx.DurationSec < int(r.maxDuration.Seconds())
+}
+
+// beatEveryoneRule — хотя бы раз победить каждого другого игрока (в команде соперников).
+type beatEveryoneRule struct{}
+
+func (beatEveryoneRule) Code() string  { return "beat_everyone" }
+func (beatEveryoneRule) Title() string { return "Гроза стола" }
+func (beatEveryoneRule) Description() string {
+	return "Победить каждого другого игрока хотя бы раз"
+}
+func (beatEveryoneRule) Check(ctx achievementContext) bool {
+	others := 0
+	for userID := range ctx.KnownPlayers {
+		if userID == ctx.UserID {
+			continue
+		}
+		if !ctx.Progress.Beaten[userID] {
+			return false
+		}
+		others++
+	}
+	return others > 0
+}
+
+// achievementRules — все правила в порядке показа в профиле.
+var achievementRules = []achievementRule{
+	firstWinRule{},
+	winStreakRule{length: 5},
+	deckVarietyRule{decks: 10},
+	fastWinRule{maxDuration: 20 * time.Minute},
+	beatEveryoneRule{},
+}
+
+// gameDurationSec — длительность завершённой партии без пауз (как в /api/stats/game-length).
+func gameDurationSec(g models.Game) int {
+	if g.EndTime == nil {
+		return 0
+	}
+	d := int(g.EndTime.Sub(g.StartTime).Seconds()) - g.TotalPauseDurationSeconds
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// achievementEngine — проигрывание партий в хронологическом порядке с проверкой правил после каждой.
+type achievementEngine struct {
+	progress map[uint]*achievementProgress
+	known    map[uint]bool
+	unlocked map[uint]map[string]bool
+}
+
+func newAchievementEngine() *achievementEngine {
+	return &achievementEngine{
+		progress: make(map[uint]*achievementProgress),
+		known:    make(map[uint]bool),
+		unlocked: make(map[uint]map[string]bool),
+	}
+}
+
+func (e *achievementEngine) playerProgress(userID uint) *achievementProgress {
+	p := e.progress[userID]
+	if p == nil {
+		p = &achievementProgress{WinDecks: make(map[int]bool), Beaten: make(map[uint]bool)}
+		e.progress[userID] = p
+	}
+	return p
+}
+
+func (e *achievementEngine) markUnlocked(userID uint, code string) {
+	if e.unlocked[userID] == nil {
+		e.unlocked[userID] = make(map[string]bool)
+	}
+	e.unlocked[userID][code] = true
+}
+
+// applyGame учитывает партию и возвращает достижения, открытые ею.
+func (e *achievementEngine) applyGame(g models.Game) []models.UserAchievement {
+	if g.EndTime == nil || g.WinningTeam == nil {
+		return nil
+	}
+	players := sortedGamePlayers(g.Players)
+	teams := make([]int, len(players))
+	for i, p := range players {
+		teams[i] = playerTeam(i, len(players))
+		e.known[p.UserID] = true
+	}
+	for i, p := range players {
+		progress := e.playerProgress(p.UserID)
+		progress.Games++
+		if teams[i] != *g.WinningTeam {
+			progress.CurrentWinStreak = 0
+			continue
+		}
+		progress.Wins++
+		progress.CurrentWinStreak++
+		progress.WinDecks[p.DeckID] = true
+		for j, o := range players {
+			if teams[j] != teams[i] {
+				progress.Beaten[o.UserID] = true
+			}
+		}
+	}
+
+	duration := gameDurationSec(g)
+	var unlocks []models.UserAchievement
+	for i, p := range players {
+		ctx := achievementContext{
+			UserID:       p.UserID,
+			Game:         g,
+			Won:          teams[i] == *g.WinningTeam,
+			DurationSec:  duration,
+			Progress:     e.playerProgress(p.UserID),
+			KnownPlayers: e.known,
+		}
+		for _, rule := range achievementRules {
+			if e.unlocked[p.UserID][rule.Code()] || !rule.Check(ctx) {
+				continue
+			}
+			e.markUnlocked(p.UserID, rule.Code())
+			unlocks = append(unlocks, models.UserAchievement{
+				UserID:     p.UserID,
+				Code:       rule.Code(),
+				GameID:     g.ID,
+				UnlockedAt: *g.EndTime,
+			})
+		}
+	}
+	return unlocks
+}
+
+// applyGameAchievements — проверка правил после завершения игры (в транзакции FinishGame).
+// История участников проигрывается заново, уже открытые достижения не дублируются.
+func applyGameAchievements(tx *gorm.DB, gameID uint) error {
+	var game models.Game
+	if err := tx.Preload("Players").First(&game, gameID).Error; err != nil {
+		return err
+	}
+	if game.EndTime == nil || game.WinningTeam == nil || len(game.Players) == 0 {
+		return nil
+	}
+	userIDs := make([]uint, 0, len(game.Players))
+	for _, p := range game.Players {
+		userIDs = append(userIDs, p.UserID)
+	}
+
+	engine := newAchievementEngine()
+	var known []uint
+	if err := tx.Raw(`
+		SELECT DISTINCT gp.user_id
+		FROM game_players gp
+		JOIN games g ON g.id = gp.game_id
+		WHERE g.end_time IS NOT NULL AND g.winning_team IS NOT NULL AND g.id <> ?
+	`, game.ID).Scan(&known).Error; err != nil {
+		return err
+	}
+	for _, id := range known {
+		engine.known[id] = true
+	}
+	var existing []models.UserAchievement
+	if err := tx.Where("user_id IN ?", userIDs).Find(&existing).Error; err != nil {
+		return err
+	}
+	for _, a := range existing {
+		engine.markUnlocked(a.UserID, a.Code)
+	}
+
+	var history []models.Game
+	if err := tx.Where("end_time IS NOT NULL AND winning_team IS NOT NULL AND id <> ?", game.ID).
+		Where("EXISTS (SELECT 1 FROM game_players hp WHERE hp.game_id = games.id AND hp.user_id IN ?)", userIDs).
+		Order("end_time ASC, id ASC").
+		Preload("Players").
+		Find(&history).Error; err != nil {
+		return err
+	}
+	// Проигрывание истории только восстанавливает прогресс: её открытия уже сохранены (или относятся к чужой неполной истории).
+	for _, g := range history {
+		engine.applyGame(g)
+	}
+	unlocks := engine.applyGame(game)
+	if len(unlocks) == 0 {
+		return nil
+	}
+	return tx.Create(&unlocks).Error
+}
+
+// recomputeAchievements — полный пересчёт достижений по всем завершённым играм. Возвращает число учтённых игр.
+func recomputeAchievements(tx *gorm.DB) (int, error) {
+	var games []models.Game
+	if err := tx.Where("end_time IS NOT NULL AND winning_team IS NOT NULL").
+		Order("end_time ASC, id ASC").
+		Preload("Players").
+		Find(&games).Error; err != nil {
+		return 0, err
+	}
+	engine := newAchievementEngine()
+	var unlocks []models.UserAchievement
+	for _, g := range games {
+		unlocks = append(unlocks, engine.applyGame(g)...)
+	}
+	if err := tx.Exec("DELETE FROM user_achievements").Error; err != nil {
+		return 0, err
+	}
+	if len(unlocks) > 0 {
+		if err := tx.CreateInBatches(&unlocks, 500).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(games), nil
+}
+
+// GetUserAchievements — все достижения с отметкой, открыто ли оно игроком, партией и временем открытия.
+func GetUserAchievements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+	db := database.GetDB()
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+	var unlocked []models.UserAchievement
+	if err := db.Where("user_id = ?", user.ID).Find(&unlocked).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить достижения"})
+		return
+	}
+	byCode := make(map[string]models.UserAchievement, len(unlocked))
+	for _, a := range unlocked {
+		byCode[a.Code] = a
+	}
+
+	_, loc, _ := resolveConfiguredTimezone()
+	resp := models.UserAchievementsResponse{
+		UserID:       user.ID,
+		PlayerName:   user.Name,
+		TotalCount:   len(achievementRules),
+		Achievements: make([]models.AchievementStatus, 0, len(achievementRules)),
+	}
+	for _, rule := range achievementRules {
+		status := models.AchievementStatus{Code: rule.Code(), Title: rule.Title(), Description: rule.Description()}
+		if a, ok := byCode[rule.Code()]; ok {
+			gameID := a.GameID
+			status.Unlocked = true
+			status.GameID = &gameID
+			status.UnlockedAt = inLocationPtr(&a.UnlockedAt, loc)
+			resp.UnlockedCount++
+		}
+		resp.Achievements = append(resp.Achievements, status)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RecomputeAchievements — полный пересчёт достижений (только админ).
+func RecomputeAchievements(c *gin.Context) {
+	db := database.GetDB()
+	tx := db.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось начать транзакцию пересчёта"})
+		return
+	}
+	games, err := recomputeAchievements(tx)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("RecomputeAchievements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересчитать достижения"})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось завершить транзакцию пересчёта"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Достижения пересчитаны", "games": games})
+}
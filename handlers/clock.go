@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetServerTime — синхронизация часов клиента; query client_send_ms — время отправки по часам клиента (unix ms).
+func GetServerTime(c *gin.Context) {
+	received := time.Now().UTC()
+
+	var clientSend *int64
+	if raw := strings.TrimSpace(c.Query("client_send_ms")); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный client_send_ms, ожидается unix-время в миллисекундах"})
+			return
+		}
+		clientSend = &v
+	}
+
+	c.Header("Cache-Control", "no-store")
+	sent := time.Now().UTC()
+	c.JSON(http.StatusOK, models.ClockSyncResponse{
+		ClientSendMs:      clientSend,
+		ServerReceiveMs:   received.UnixMilli(),
+		ServerSendMs:      sent.UnixMilli(),
+		ServerReceiveTime: received,
+		ServerSendTime:    sent,
+	})
+}
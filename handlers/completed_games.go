@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// completedGameClockSkew — допуск на расхождение часов клиента при проверке end_time «в будущем».
+const completedGameClockSkew = 5 * time.Minute
+
+// validateCompletedGame проверяет поля ретроспективной игры и возвращает время начала и конца (UTC).
+func validateCompletedGame(req models.CreateCompletedGameRequest, now time.Time) (time.Time, time.Time, error) {
+	if req.FirstMoveTeam < 1 || req.FirstMoveTeam > 2 {
+		return time.Time{}, time.Time{}, errors.New("first_move_team должен быть 1 или 2")
+	}
+	if req.WinningTeam < 1 || req.WinningTeam > 2 {
+		return time.Time{}, time.Time{}, errors.New("winning_team должен быть 1 или 2")
+	}
+	if len(req.Players) < 2 {
+		return time.Time{}, time.Time{}, errors.New("Укажите хотя бы двух игроков")
+	}
+	if req.StartTime == nil || req.StartTime.T == nil || req.EndTime == nil || req.EndTime.T == nil {
+		return time.Time{}, time.Time{}, errors.New("start_time и end_time обязательны")
+	}
+	start, end := *req.StartTime.T, *req.EndTime.T
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("end_time должен быть позже start_time")
+	}
+	if end.After(now.Add(completedGameClockSkew)) {
+		return time.Time{}, time.Time{}, errors.New("end_time не может быть в будущем")
+	}
+	duration := int(end.Sub(start).Seconds())
+	if req.TotalPauseDurationSeconds < 0 || req.TotalPauseDurationSeconds > duration {
+		return time.Time{}, time.Time{}, errors.New("total_pause_duration_seconds вне длительности игры")
+	}
+
+	turnsTotal := 0
+	for i, t := range req.Turns {
+		if t.TeamNumber < 1 || t.TeamNumber > 2 {
+			return time.Time{}, time.Time{}, fmt.Errorf("turns[%d]: team_number должен быть 1 или 2", i)
+		}
+		if t.Duration < 0 || t.Overtime < 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("turns[%d]: длительность не может быть отрицательной", i)
+		}
+		for _, ts := range []*time.Time{t.StartedAt.Time(), t.EndedAt.Time()} {
+			if ts != nil && (ts.Before(start) || ts.After(end)) {
+				return time.Time{}, time.Time{}, fmt.Errorf("turns[%d]: время хода вне интервала игры", i)
+			}
+		}
+		if started, ended := t.StartedAt.Time(), t.EndedAt.Time(); started != nil && ended != nil && ended.Before(*started) {
+			return time.Time{}, time.Time{}, fmt.Errorf("turns[%d]: ended_at раньше started_at", i)
+		}
+		turnsTotal += t.Duration
+	}
+	if turnsTotal > duration {
+		return time.Time{}, time.Time{}, errors.New("Суммарная длительность ходов больше длительности игры")
+	}
+	return start, end, nil
+}
+
+// resolveCompletedGamePlayers проверяет, что пользователи существуют и не повторяются, и подставляет названия колод.
+func resolveCompletedGamePlayers(db *gorm.DB, players []models.GamePlayer) error {
+	userIDs := make([]uint, 0, len(players))
+	seen := make(map[uint]bool, len(players))
+	deckIDs := make([]int, 0, len(players))
+	for _, p := range players {
+		if seen[p.UserID] {
+			return fmt.Errorf("Игрок %d указан дважды", p.UserID)
+		}
+		seen[p.UserID] = true
+		userIDs = append(userIDs, p.UserID)
+		if p.DeckID > 0 {
+			deckIDs = append(deckIDs, p.DeckID)
+		}
+	}
+
+	var usersCount int64
+	if err := db.Model(&models.User{}).Where("id IN ?", userIDs).Count(&usersCount).Error; err != nil {
+		return err
+	}
+	if int(usersCount) != len(userIDs) {
+		return errors.New("Некоторые игроки не найдены")
+	}
+
+	if len(deckIDs) == 0 {
+		return nil
+	}
+	var decks []models.Deck
+	if err := db.Where("id IN ?", deckIDs).Find(&decks).Error; err != nil {
+		return err
+	}
+	deckNames := make(map[int]string, len(decks))
+	for _, d := range decks {
+		deckNames[int(d.ID)] = d.Name
+	}
+	for i := range players {
+		if players[i].DeckID <= 0 {
+			continue
+		}
+		name, ok := deckNames[players[i].DeckID]
+		if !ok {
+			return fmt.Errorf("Колода %d не найдена", players[i].DeckID)
+		}
+		if players[i].DeckName == "" {
+			players[i].DeckName = name
+		}
+	}
+	return nil
+}
+
+// CreateCompletedGame — запись уже сыгранной игры задним числом (время начала/конца от клиента).
+// Не создаёт активную игру и не конфликтует с текущей активной партией.
+func CreateCompletedGame(c *gin.Context) {
+	var req models.CreateCompletedGameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	start, end, err := validateCompletedGame(req, now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	players, err := gamePlayersFromInput(req.Players)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	if err := resolveCompletedGamePlayers(db, players); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := uniqueViewToken(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сгенерировать публичный токен"})
+		return
+	}
+
+	winningTeam := req.WinningTeam
+	game := models.Game{
+		ViewToken:                 token,
+		StartTime:                 start,
+		EndTime:                   &end,
+		TurnLimitSeconds:          req.TurnLimitSeconds,
+		TeamTimeLimitSeconds:      req.TeamTimeLimitSeconds,
+		FirstMoveTeam:             req.FirstMoveTeam,
+		Team1Name:                 req.Team1Name,
+		Team2Name:                 req.Team2Name,
+		TotalPauseDurationSeconds: req.TotalPauseDurationSeconds,
+		IsTechnicalDefeat:         req.IsTechnicalDefeat,
+		WinningTeam:               &winningTeam,
+		CreatedAt:                 now,
+		UpdatedAt:                 now,
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить игру"})
+		return
+	}
+	if err := tx.Create(&game).Error; err != nil {
+		tx.Rollback()
+		log.Printf("CreateCompletedGame: create game: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить игру"})
+		return
+	}
+	gps := make([]models.GamePlayer, 0, len(players))
+	for _, p := range players {
+		gps = append(gps, models.GamePlayer{
+			GameID:   game.ID,
+			UserID:   p.UserID,
+			DeckID:   p.DeckID,
+			DeckName: p.DeckName,
+		})
+	}
+	if err := tx.Create(&gps).Error; err != nil {
+		tx.Rollback()
+		log.Printf("CreateCompletedGame: create players: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить игроков игры"})
+		return
+	}
+	if len(req.Turns) > 0 {
+		// Время ходов — только то, что прислал клиент; иначе хронология восстановит его по длительностям.
+		turns := make([]models.GameTurn, len(req.Turns))
+		for i, t := range req.Turns {
+			turns[i] = models.GameTurn{
+				GameID:     game.ID,
+				TeamNumber: t.TeamNumber,
+				Duration:   t.Duration,
+				Overtime:   t.Overtime,
+				StartedAt:  t.StartedAt.Time(),
+				EndedAt:    t.EndedAt.Time(),
+			}
+		}
+		if err := tx.Omit("ID").Create(&turns).Error; err != nil {
+			tx.Rollback()
+			log.Printf("CreateCompletedGame: create turns: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить ходы игры"})
+			return
+		}
+	}
+	// Игра задним числом меняет порядок партий — рейтинги, агрегаты статистики и достижения пересчитываются целиком.
+	if _, err := recomputeRatings(tx); err != nil {
+		tx.Rollback()
+		log.Printf("CreateCompletedGame: ratings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересчитать рейтинги"})
+		return
+	}
+	if _, err := rebuildStatsAggregates(tx); err != nil {
+		tx.Rollback()
+		log.Printf("CreateCompletedGame: stats aggregates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересобрать агрегаты статистики"})
+		return
+	}
+	if _, err := recomputeAchievements(tx); err != nil {
+		tx.Rollback()
+		log.Printf("CreateCompletedGame: achievements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересчитать достижения"})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить игру"})
+		return
+	}
+	invalidateStatsCache()
+
+	db.Scopes(withGameAssociations).First(&game, game.ID)
+	respondGame(c, http.StatusCreated, game)
+}
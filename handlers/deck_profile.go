@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deckProfileMatchups — матчапов в лучших и худших в профиле колоды.
+const deckProfileMatchups = 3
+
+// GetDeckProfile — профиль колоды: процент побед по периодам (group_by, по умолчанию month), лучшие и худшие матчапы
+// (от min_games партий, по умолчанию profileMinGames), самые успешные пилоты, длительность партий, первый и второй ход,
+// последние партии. Поддерживает общие фильтры (statsFilter).
+func GetDeckProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID колоды"})
+		return
+	}
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	groupBy := c.DefaultQuery("group_by", "month")
+	if groupBy != "day" && groupBy != "week" && groupBy != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by должен быть day|week|month"})
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	var deck models.Deck
+	if err := db.First(&deck, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Колода не найдена"})
+		return
+	}
+
+	type participantRow struct {
+		GameID        uint      `gorm:"column:game_id"`
+		UserID        uint      `gorm:"column:user_id"`
+		PlayerName    string    `gorm:"column:player_name"`
+		DeckID        int       `gorm:"column:deck_id"`
+		DeckName      string    `gorm:"column:deck_name"`
+		StartTime     time.Time `gorm:"column:start_time"`
+		EndTime       time.Time `gorm:"column:end_time"`
+		WinningTeam   int       `gorm:"column:winning_team"`
+		FirstMoveTeam int       `gorm:"column:first_move_team"`
+		PlayerTeam    int       `gorm:"column:player_team"`
+	}
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT game_id, user_id, player_name, deck_id, deck_name, start_time, end_time, winning_team, first_move_team, player_team
+		FROM players_with_team
+		WHERE game_id IN (SELECT game_id FROM game_players WHERE deck_id = ?)
+		ORDER BY end_time DESC, game_id DESC, player_index ASC
+	`, playersWithTeamCTE(whereClause))
+	args := append(append([]interface{}{}, whereArgs...), deck.ID)
+	var rows []participantRow
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		log.Printf("GetDeckProfile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить профиль колоды"})
+		return
+	}
+	lengths, err := loadGameLengths(db, whereClause+" AND g.id IN (SELECT game_id FROM game_players WHERE deck_id = ?)", args)
+	if err != nil {
+		log.Printf("GetDeckProfile: game length: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить длительность партий"})
+		return
+	}
+	matchupRows, err := loadDeckMatchups(db, filter, filter.minGamesOr(profileMinGames), int(deck.ID))
+	if err != nil {
+		log.Printf("GetDeckProfile: matchups: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить матчапы колоды"})
+		return
+	}
+
+	// Партии от новых к старым, участники — в порядке добавления.
+	var gameOrder []uint
+	byGame := make(map[uint][]participantRow)
+	for _, r := range rows {
+		if _, seen := byGame[r.GameID]; !seen {
+			gameOrder = append(gameOrder, r.GameID)
+		}
+		byGame[r.GameID] = append(byGame[r.GameID], r)
+	}
+
+	loc := filter.Location
+	resp := models.DeckProfileResponse{
+		DeckID:          int(deck.ID),
+		DeckName:        deck.Name,
+		GroupBy:         groupBy,
+		WinRateOverTime: []models.DeckProfilePeriod{},
+		MatchupMinGames: filter.minGamesOr(profileMinGames),
+		BestMatchups:    []models.DeckProfileMatchup{},
+		WorstMatchups:   []models.DeckProfileMatchup{},
+		Pilots:          []models.DeckProfilePilot{},
+		GameLength:      gameLengthSummary(lengths),
+		RecentGames:     make([]models.DeckProfileGame, 0, profileRecentGames),
+	}
+	var games, wins, firstGames, firstWins, secondGames, secondWins int
+	pilots := make(map[int]*profileTally)
+	periods := make(map[string]*profileTally)
+	for _, gameID := range gameOrder {
+		participants := byGame[gameID]
+		recent := models.DeckProfileGame{Pilots: []string{}, OpponentDecks: []string{}}
+		appeared := false
+		for _, me := range participants {
+			if me.DeckID != int(deck.ID) {
+				continue
+			}
+			won := me.PlayerTeam == me.WinningTeam
+			games++
+			if won {
+				wins++
+			}
+			if me.PlayerTeam == me.FirstMoveTeam {
+				firstGames++
+				if won {
+					firstWins++
+				}
+			} else {
+				secondGames++
+				if won {
+					secondWins++
+				}
+			}
+			tallyAdd(pilots, int(me.UserID), me.PlayerName, won)
+			period := periodKey(me.StartTime, groupBy, loc)
+			if periods[period] == nil {
+				periods[period] = &profileTally{name: period}
+			}
+			periods[period].games++
+			if won {
+				periods[period].wins++
+			}
+
+			if !appeared {
+				appeared = true
+				recent.GameID = gameID
+				recent.StartTime = inLocation(me.StartTime, loc)
+				recent.EndTime = inLocation(me.EndTime, loc)
+				recent.Won = won
+				for _, p := range participants {
+					if p.PlayerTeam != me.PlayerTeam {
+						recent.OpponentDecks = append(recent.OpponentDecks, p.DeckName)
+					}
+				}
+			}
+			recent.Pilots = append(recent.Pilots, me.PlayerName)
+		}
+		if appeared && len(resp.RecentGames) < profileRecentGames {
+			resp.RecentGames = append(resp.RecentGames, recent)
+		}
+	}
+
+	resp.Overall = profileRecord(games, wins)
+	resp.FirstMove = profileRecord(firstGames, firstWins)
+	resp.SecondMove = profileRecord(secondGames, secondWins)
+
+	keys := make([]string, 0, len(periods))
+	for k := range periods {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var cumGames, cumWins int
+	for _, k := range keys {
+		p := periods[k]
+		cumGames += p.games
+		cumWins += p.wins
+		resp.WinRateOverTime = append(resp.WinRateOverTime, models.DeckProfilePeriod{
+			Period:            k,
+			ProfileRecord:     profileRecord(p.games, p.wins),
+			CumulativeWinRate: float64(cumWins) / float64(cumGames) * 100,
+		})
+	}
+
+	// Матчапы — те же пары, что в /api/stats/deck-matchups, с точки зрения этой колоды.
+	qualified := make([]models.DeckProfileMatchup, 0, len(matchupRows))
+	for _, m := range matchupRows {
+		oppID, oppName, wins := m.Deck2ID, m.Deck2Name, m.Deck1Wins
+		if m.Deck1ID != int(deck.ID) {
+			oppID, oppName, wins = m.Deck1ID, m.Deck1Name, m.Deck2Wins
+		}
+		qualified = append(qualified, models.DeckProfileMatchup{
+			OpponentDeckID:    oppID,
+			OpponentDeckName:  oppName,
+			ProfileRecord:     profileRecord(m.GamesCount, wins),
+			WinRateConfidence: winRateConfidence(wins, m.GamesCount),
+		})
+	}
+	sort.Slice(qualified, func(i, j int) bool {
+		a, b := qualified[i], qualified[j]
+		if a.WinRate != b.WinRate {
+			return a.WinRate > b.WinRate
+		}
+		if a.GamesCount != b.GamesCount {
+			return a.GamesCount > b.GamesCount
+		}
+		return a.OpponentDeckName < b.OpponentDeckName
+	})
+	// Лучшие — сверху списка, худшие — снизу, без пересечения при малом числе матчапов.
+	best := deckProfileMatchups
+	if best > len(qualified) {
+		best = len(qualified)
+	}
+	resp.BestMatchups = append(resp.BestMatchups, qualified[:best]...)
+	for i := len(qualified) - 1; i >= best && len(resp.WorstMatchups) < deckProfileMatchups; i-- {
+		resp.WorstMatchups = append(resp.WorstMatchups, qualified[i])
+	}
+
+	for userID, t := range pilots {
+		resp.Pilots = append(resp.Pilots, models.DeckProfilePilot{
+			UserID:        uint(userID),
+			PlayerName:    t.name,
+			ProfileRecord: profileRecord(t.games, t.wins),
+		})
+	}
+	sort.Slice(resp.Pilots, func(i, j int) bool {
+		a, b := resp.Pilots[i], resp.Pilots[j]
+		if a.WinsCount != b.WinsCount {
+			return a.WinsCount > b.WinsCount
+		}
+		if a.WinRate != b.WinRate {
+			return a.WinRate > b.WinRate
+		}
+		return a.PlayerName < b.PlayerName
+	})
+
+	writeStatsCacheJSON(c, resp)
+}
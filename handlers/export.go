@@ -15,6 +15,7 @@ import (
 	"mtg-stats-backend/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // ExportUser — пользователь для экспорта; password_hash только при ?include_passwords=true.
@@ -34,11 +35,15 @@ type ExportDeck struct {
 	AvatarBase64 string `json:"avatar_base64,omitempty"`
 }
 
-// ExportPayload — полный дамп данных (пользователи, колоды, игры с игроками и ходами).
+// ExportPayload — полный дамп данных (пользователи, колоды, игры с игроками, ходами и паузами,
+// сезоны с замороженными таблицами закрытых сезонов, турниры с участниками и матчами, матчи из нескольких партий).
 type ExportPayload struct {
-	Users []ExportUser  `json:"users"`
-	Decks []ExportDeck  `json:"decks"`
-	Games []models.Game `json:"games"`
+	Users       []ExportUser        `json:"users"`
+	Decks       []ExportDeck        `json:"decks"`
+	Games       []models.Game       `json:"games"`
+	Seasons     []models.Season     `json:"seasons"`
+	Tournaments []models.Tournament `json:"tournaments"`
+	Matches     []models.Match      `json:"matches"`
 }
 
 func fileBase64FromImageURL(imageURL string) (string, error) {
@@ -88,11 +93,35 @@ func buildExportPayload(c *gin.Context, includePasswords bool) (*ExportPayload,
 	}
 
 	var games []models.Game
-	if err := db.Order("updated_at DESC").Preload("Players.User").Preload("Turns").Find(&games).Error; err != nil {
+	if err := db.Order("updated_at DESC").Scopes(withGameAssociations).Find(&games).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список игр"})
 		return nil, false
 	}
 
+	var seasons []models.Season
+	if err := db.Order("id ASC").Preload("Standings", func(db *gorm.DB) *gorm.DB {
+		return db.Order("rank ASC, id ASC")
+	}).Find(&seasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список сезонов"})
+		return nil, false
+	}
+
+	var tournaments []models.Tournament
+	if err := db.Order("id ASC").Preload("Players", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Matches", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Find(&tournaments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список турниров"})
+		return nil, false
+	}
+
+	var matches []models.Match
+	if err := db.Order("id ASC").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список матчей"})
+		return nil, false
+	}
+
 	exportDecks := make([]ExportDeck, 0, len(decks))
 	for _, d := range decks {
 		img, err := fileBase64FromImageURL(d.ImageURL)
@@ -113,14 +142,17 @@ func buildExportPayload(c *gin.Context, includePasswords bool) (*ExportPayload,
 	}
 
 	payload := &ExportPayload{
-		Users: exportUsers,
-		Decks: exportDecks,
-		Games: games,
+		Users:       exportUsers,
+		Decks:       exportDecks,
+		Games:       games,
+		Seasons:     seasons,
+		Tournaments: tournaments,
+		Matches:     matches,
 	}
 	return payload, true
 }
 
-// ExportAllData — экспорт всех данных БД (users, decks, games) с инлайновыми картинками колод в gzip-архиве JSON.
+// ExportAllData — экспорт всех данных БД (users, decks, games, seasons, tournaments) с инлайновыми картинками колод в gzip-архиве JSON.
 // Query: include_passwords=true — включить хеши паролей (полный бэкап); по умолчанию — без паролей.
 func ExportAllData(c *gin.Context) {
 	includePasswords := c.Query("include_passwords") == "true"
@@ -184,7 +216,7 @@ func restoreDeckImagesFromExport(decks []ExportDeck) error {
 	return nil
 }
 
-// importAllDataFromPayload выполняет TRUNCATE таблиц, восстанавливает users/decks/games из payload,
+// importAllDataFromPayload выполняет TRUNCATE таблиц, восстанавливает users/decks/games/seasons/tournaments из payload,
 // затем записывает изображения колод на диск из base64.
 func importAllDataFromPayload(c *gin.Context, payload *ExportPayload) {
 	db := database.GetDB()
@@ -195,7 +227,7 @@ func importAllDataFromPayload(c *gin.Context, payload *ExportPayload) {
 	}
 
 	// TRUNCATE RESTART IDENTITY сбрасывает последовательности, чтобы новые ID совпадали с порядком в payload.
-	if err := tx.Exec("TRUNCATE users, decks, games RESTART IDENTITY CASCADE").Error; err != nil {
+	if err := tx.Exec("TRUNCATE users, decks, games, seasons, season_standings, tournaments, tournament_players, tournament_matches, matches, leaderboard_snapshots, leaderboard_entries RESTART IDENTITY CASCADE").Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить таблицы", "details": err.Error()})
 		return
@@ -234,12 +266,14 @@ func importAllDataFromPayload(c *gin.Context, payload *ExportPayload) {
 		}
 	}
 
-	// Восстанавливаем игры, игроков и ходы.
+	// Восстанавливаем игры, игроков, ходы и паузы.
 	for _, g := range payload.Games {
 		players := g.Players
 		turns := g.Turns
+		pauses := g.Pauses
 		g.Players = nil
 		g.Turns = nil
+		g.Pauses = nil
 
 		if err := tx.Create(&g).Error; err != nil {
 			tx.Rollback()
@@ -280,6 +314,8 @@ func importAllDataFromPayload(c *gin.Context, payload *ExportPayload) {
 					TeamNumber: t.TeamNumber,
 					Duration:   t.Duration,
 					Overtime:   t.Overtime,
+					StartedAt:  t.StartedAt,
+					EndedAt:    t.EndedAt,
 				})
 			}
 			if err := tx.Create(&gts).Error; err != nil {
@@ -288,6 +324,69 @@ func importAllDataFromPayload(c *gin.Context, payload *ExportPayload) {
 				return
 			}
 		}
+
+		if len(pauses) > 0 {
+			gps := make([]models.GamePause, 0, len(pauses))
+			for _, p := range pauses {
+				gps = append(gps, models.GamePause{
+					GameID:      g.ID,
+					StartedAt:   p.StartedAt,
+					EndedAt:     p.EndedAt,
+					Reason:      p.Reason,
+					ActorUserID: p.ActorUserID,
+					ActorName:   p.ActorName,
+				})
+			}
+			if err := tx.Create(&gps).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось восстановить паузы игры"})
+				return
+			}
+		}
+	}
+
+	// Сезоны — вместе со снимками таблиц закрытых сезонов (standings создаются как ассоциация).
+	if len(payload.Seasons) > 0 {
+		if err := tx.Create(&payload.Seasons).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось восстановить сезоны", "details": err.Error()})
+			return
+		}
+	}
+
+	// Турниры — после игр, на которые ссылаются матчи (участники и матчи создаются как ассоциации).
+	if len(payload.Tournaments) > 0 {
+		if err := tx.Create(&payload.Tournaments).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось восстановить турниры", "details": err.Error()})
+			return
+		}
+	}
+
+	// Матчи — счёт уже в архиве, партии ссылаются на них через games.match_id.
+	if len(payload.Matches) > 0 {
+		if err := tx.Create(&payload.Matches).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось восстановить матчи", "details": err.Error()})
+			return
+		}
+	}
+
+	// Рейтинги, агрегаты статистики и достижения не входят в архив — пересчитываются по импортированным играм.
+	if _, err := recomputeRatings(tx); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересчитать рейтинги", "details": err.Error()})
+		return
+	}
+	if _, err := rebuildStatsAggregates(tx); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересобрать агрегаты статистики", "details": err.Error()})
+		return
+	}
+	if _, err := recomputeAchievements(tx); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересчитать достижения", "details": err.Error()})
+		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// gamePrecondition — ожидаемое состояние игры из If-Match или expected_version.
+// gameID == 0 — ID игры не указан (только версия).
+type gamePrecondition struct {
+	gameID  uint
+	version int64
+}
+
+// gameETag — ETag вида "<id>-<version>"; меняется при каждом изменении партии.
+func gameETag(g models.Game) string {
+	return fmt.Sprintf(`"%d-%d"`, g.ID, g.Version)
+}
+
+// parseGameETag разбирает значение If-Match: "<id>-<version>", "<version>" или W/"...".
+func parseGameETag(raw string) (*gamePrecondition, error) {
+	v := strings.TrimSpace(raw)
+	v = strings.TrimPrefix(v, "W/")
+	v = strings.Trim(v, `"`)
+	if v == "" {
+		return nil, fmt.Errorf("пустой ETag")
+	}
+	pre := &gamePrecondition{}
+	if idx := strings.LastIndex(v, "-"); idx > 0 {
+		id, err := strconv.ParseUint(v[:idx], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		pre.gameID = uint(id)
+		v = v[idx+1:]
+	}
+	version, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	pre.version = version
+	return pre, nil
+}
+
+// readGamePrecondition — условие записи из If-Match, затем expected_version (тело или query).
+// nil без ошибки — клиент не просил проверку, запись безусловная (как раньше). При ошибке пишет 400.
+func readGamePrecondition(c *gin.Context, bodyVersion *int64) (*gamePrecondition, bool) {
+	if raw := strings.TrimSpace(c.GetHeader("If-Match")); raw != "" && raw != "*" {
+		pre, err := parseGameETag(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный заголовок If-Match"})
+			return nil, false
+		}
+		return pre, true
+	}
+	if bodyVersion != nil {
+		return &gamePrecondition{version: *bodyVersion}, true
+	}
+	if raw := strings.TrimSpace(c.Query("expected_version")); raw != "" {
+		version, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный expected_version"})
+			return nil, false
+		}
+		return &gamePrecondition{version: version}, true
+	}
+	return nil, true
+}
+
+func (p *gamePrecondition) matches(g models.Game) bool {
+	if p == nil {
+		return true
+	}
+	if p.gameID != 0 && p.gameID != g.ID {
+		return false
+	}
+	return p.version == g.Version
+}
+
+// orReadVersion — условие клиента, а без него — версия, прочитанная обработчиком. Для записей, которые открывают
+// или закрывают интервал паузы: параллельный запрос без If-Match не должен применить их второй раз.
+func (p *gamePrecondition) orReadVersion(g models.Game) *gamePrecondition {
+	if p != nil {
+		return p
+	}
+	return &gamePrecondition{gameID: g.ID, version: g.Version}
+}
+
+// updateGameVersioned — UPDATE игры с инкрементом version; при заданном условии — только если версия не изменилась.
+// false без ошибки — игру успели изменить (гонка между устройствами).
+func updateGameVersioned(db *gorm.DB, game *models.Game, pre *gamePrecondition, updates map[string]interface{}) (bool, error) {
+	q := db.Model(&models.Game{}).Where("id = ?", game.ID)
+	if pre != nil {
+		q = q.Where("version = ?", pre.version)
+	}
+	updates["version"] = gorm.Expr("version + 1")
+	res := q.Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	game.Version++
+	return true, nil
+}
+
+// writeGamePreconditionFailed — 412 с актуальным состоянием игры, чтобы клиент мог пересинхронизироваться.
+func writeGamePreconditionFailed(c *gin.Context, db *gorm.DB, gameID uint) {
+	var current models.Game
+	if err := db.Scopes(withGameAssociations).First(&current, gameID).Error; err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Игра была изменена другим устройством"})
+		return
+	}
+	c.Header("ETag", gameETag(current))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":           "Игра была изменена другим устройством",
+		"current_version": current.Version,
+		"game":            gameResponse(current, gameViewer(c)),
+	})
+}
+
+// respondGame — ответ с игрой и заголовком ETag.
+func respondGame(c *gin.Context, status int, g models.Game) {
+	c.Header("ETag", gameETag(g))
+	c.JSON(status, gameResponse(g, gameViewer(c)))
+}
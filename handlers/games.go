@@ -3,10 +3,12 @@ package handlers
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"log"
 	mathrand "math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"mtg-stats-backend/database"
@@ -56,10 +58,28 @@ func gameResponse(g models.Game, viewer *middleware.UserInfo) models.GameRespons
 }
 
 // GetGames — список игр с игроками и ходами; is_admin в players маскируется.
+// user_id — только игры с участием игрока, limit — не больше limit последних игр.
 func GetGames(c *gin.Context) {
 	db := database.GetDB()
+	query := db.Order("updated_at DESC").Scopes(withGameAssociations)
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := strconv.Atoi(raw)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный user_id"})
+			return
+		}
+		query = query.Where("id IN (SELECT game_id FROM game_players WHERE user_id = ?)", userID)
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return
+		}
+		query = query.Limit(limit)
+	}
 	var games []models.Game
-	result := db.Order("updated_at DESC").Preload("Players.User").Preload("Turns").Find(&games)
+	result := query.Find(&games)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список игр"})
 		return
@@ -81,11 +101,37 @@ func GetGame(c *gin.Context) {
 	}
 	db := database.GetDB()
 	var game models.Game
-	if err := db.Preload("Players.User").Preload("Turns").First(&game, id).Error; err != nil {
+	if err := db.Scopes(withGameAssociations).First(&game, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Игра не найдена"})
 		return
 	}
-	c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+	respondGame(c, http.StatusOK, game)
+}
+
+// gamePlayersFromInput — игроки из запроса: user_id/user_name или вложенный user; порядок задаёт команды.
+func gamePlayersFromInput(inputs []models.CreateGamePlayerInput) ([]models.GamePlayer, error) {
+	players := make([]models.GamePlayer, 0, len(inputs))
+	for _, p := range inputs {
+		var userID uint
+		var userName string
+		if p.User != nil && p.User.ID != 0 {
+			userID = p.User.ID
+			userName = p.User.Name
+		} else {
+			userID = uint(p.UserID)
+			userName = p.UserName
+		}
+		if userID == 0 {
+			return nil, errors.New("У каждого игрока должен быть user_id или user.id")
+		}
+		players = append(players, models.GamePlayer{
+			UserID:   userID,
+			User:     models.User{ID: userID, Name: userName},
+			DeckID:   p.DeckID,
+			DeckName: p.DeckName,
+		})
+	}
+	return players, nil
 }
 
 // CreateGame — создание активной игры; first_move_team 1 или 2; 409 если активная уже есть.
@@ -133,27 +179,12 @@ func CreateGame(c *gin.Context) {
 		return
 	}
 	game.ViewToken = token
-	for _, p := range req.Players {
-		var userID uint
-		var userName string
-		if p.User != nil && p.User.ID != 0 {
-			userID = p.User.ID
-			userName = p.User.Name
-		} else {
-			userID = uint(p.UserID)
-			userName = p.UserName
-		}
-		if userID == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "У каждого игрока должен быть user_id или user.id"})
-			return
-		}
-		game.Players = append(game.Players, models.GamePlayer{
-			UserID:   userID,
-			User:     models.User{ID: userID, Name: userName},
-			DeckID:   p.DeckID,
-			DeckName: p.DeckName,
-		})
+	players, err := gamePlayersFromInput(req.Players)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	game.Players = players
 
 	if err := db.Session(&gorm.Session{FullSaveAssociations: true}).Create(game).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать игру"})
@@ -161,8 +192,8 @@ func CreateGame(c *gin.Context) {
 	}
 	invalidateStatsCache()
 
-	db.Preload("Players.User").Preload("Turns").First(game, game.ID)
-	c.JSON(http.StatusCreated, gameResponse(*game, gameViewer(c)))
+	db.Scopes(withGameAssociations).First(game, game.ID)
+	respondGame(c, http.StatusCreated, *game)
 }
 
 // GetGameByPublicToken — read-only просмотр игры без авторизации по публичному токену.
@@ -174,7 +205,7 @@ func GetGameByPublicToken(c *gin.Context) {
 	}
 	db := database.GetDB()
 	var game models.Game
-	if err := db.Where("view_token = ?", token).Preload("Players.User").Preload("Turns").First(&game).Error; err != nil {
+	if err := db.Where("view_token = ?", token).Scopes(withGameAssociations).First(&game).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Игра не найдена"})
 		return
 	}
@@ -219,7 +250,7 @@ func CreateRematch(c *gin.Context) {
 	}
 
 	var source models.Game
-	if err := db.Preload("Players.User").Preload("Turns").First(&source, req.SourceGameID).Error; err != nil {
+	if err := db.Scopes(withGameAssociations).First(&source, req.SourceGameID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Исходная игра не найдена"})
 		return
 	}
@@ -311,109 +342,210 @@ func CreateRematch(c *gin.Context) {
 		return
 	}
 	invalidateStatsCache()
-	db.Preload("Players.User").Preload("Turns").First(&rematch, rematch.ID)
-	c.JSON(http.StatusCreated, gameResponse(rematch, gameViewer(c)))
+	db.Scopes(withGameAssociations).First(&rematch, rematch.ID)
+	respondGame(c, http.StatusCreated, rematch)
 }
 
-// PauseGame — поставить партию на паузу; 404 если нет активной.
+// PauseGame — поставить партию на паузу (тело необязательно: reason); интервал паузы сохраняется в game_pauses.
+// 404 если нет активной; 412 при устаревшем If-Match/expected_version.
 func PauseGame(c *gin.Context) {
+	var req models.PauseGameRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	pre, ok := readGamePrecondition(c, nil)
+	if !ok {
+		return
+	}
 	db := database.GetDB()
 	var game models.Game
 	if err := db.Where("end_time IS NULL").First(&game).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Нет активной игры"})
 		return
 	}
+	if !pre.matches(game) {
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
 	if game.IsPaused {
-		db.Preload("Players.User").Preload("Turns").First(&game, game.ID)
-		c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+		db.Scopes(withGameAssociations).First(&game, game.ID)
+		respondGame(c, http.StatusOK, game)
 		return
 	}
 	now := time.Now().UTC()
 	game.IsPaused = true
 	game.PauseStartedAt = &now
-	if err := db.Model(&game).Updates(map[string]interface{}{
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось поставить на паузу"})
+		return
+	}
+	applied, err := updateGameVersioned(tx, &game, pre.orReadVersion(game), map[string]interface{}{
 		"is_paused":        true,
 		"pause_started_at": &now,
-	}).Error; err != nil {
+	})
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось поставить на паузу"})
+		return
+	}
+	if !applied {
+		tx.Rollback()
+		if pre == nil {
+			// Игру успел изменить параллельный запрос — интервал не создаётся, отдаём актуальное состояние.
+			db.Scopes(withGameAssociations).First(&game, game.ID)
+			respondGame(c, http.StatusOK, game)
+			return
+		}
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
+	pause := newGamePause(game.ID, now, strings.TrimSpace(req.Reason), gameViewer(c))
+	if err := tx.Create(&pause).Error; err != nil {
+		tx.Rollback()
+		log.Printf("PauseGame: create pause: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось поставить на паузу"})
 		return
 	}
-	db.Preload("Players.User").Preload("Turns").First(&game, game.ID)
-	c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось поставить на паузу"})
+		return
+	}
+	db.Scopes(withGameAssociations).First(&game, game.ID)
+	respondGame(c, http.StatusOK, game)
 }
 
-// ResumeGame — снять паузу; время паузы не идёт в общее и в ход; 404 если нет активной.
+// ResumeGame — снять паузу: закрывает интервал в game_pauses и пересчитывает общее время пауз по интервалам.
+// Начало текущего хода не сдвигается в БД — сдвиг на паузы считается при выдаче ответа.
+// 404 если нет активной; 412 при устаревшей версии.
 func ResumeGame(c *gin.Context) {
+	pre, ok := readGamePrecondition(c, nil)
+	if !ok {
+		return
+	}
 	db := database.GetDB()
 	var game models.Game
 	if err := db.Where("end_time IS NULL").First(&game).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Нет активной игры"})
 		return
 	}
+	if !pre.matches(game) {
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
 	if !game.IsPaused || game.PauseStartedAt == nil {
-		db.Preload("Players.User").Preload("Turns").First(&game, game.ID)
-		c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+		db.Scopes(withGameAssociations).First(&game, game.ID)
+		respondGame(c, http.StatusOK, game)
 		return
 	}
 	now := time.Now().UTC()
-	pauseDuration := now.Sub(*game.PauseStartedAt)
-	game.TotalPauseDurationSeconds += int(pauseDuration.Seconds())
-	if game.CurrentTurnStart != nil {
-		adjusted := game.CurrentTurnStart.Add(pauseDuration)
-		game.CurrentTurnStart = &adjusted
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось снять паузу"})
+		return
 	}
+	totalPause, err := closeOpenPauses(tx, game, now)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("ResumeGame: close pauses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось снять паузу"})
+		return
+	}
+	game.TotalPauseDurationSeconds = totalPause
 	game.IsPaused = false
 	game.PauseStartedAt = nil
 	updates := map[string]interface{}{
 		"is_paused":                    false,
 		"pause_started_at":             nil,
 		"total_pause_duration_seconds": game.TotalPauseDurationSeconds,
-		"current_turn_start":           game.CurrentTurnStart,
 	}
-	if err := db.Model(&game).Updates(updates).Error; err != nil {
+	applied, err := updateGameVersioned(tx, &game, pre.orReadVersion(game), updates)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось снять паузу"})
+		return
+	}
+	if !applied {
+		tx.Rollback()
+		if pre == nil {
+			// Паузу уже снял параллельный запрос — закрытие интервалов откатывается, отдаём актуальное состояние.
+			db.Scopes(withGameAssociations).First(&game, game.ID)
+			respondGame(c, http.StatusOK, game)
+			return
+		}
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось снять паузу"})
 		return
 	}
-	db.Preload("Players.User").Preload("Turns").First(&game, game.ID)
-	c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+	invalidateStatsCache()
+	db.Scopes(withGameAssociations).First(&game, game.ID)
+	respondGame(c, http.StatusOK, game)
 }
 
-// StartTurn — установить начало текущего хода (серверное время); 404 если нет активной игры.
+// StartTurn — установить начало текущего хода (серверное время); 404 если нет активной игры; 412 при устаревшей версии.
 func StartTurn(c *gin.Context) {
+	pre, ok := readGamePrecondition(c, nil)
+	if !ok {
+		return
+	}
 	db := database.GetDB()
 	var game models.Game
 	if err := db.Where("end_time IS NULL").First(&game).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Нет активной игры"})
 		return
 	}
+	if !pre.matches(game) {
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
 	now := time.Now().UTC()
 	game.CurrentTurnStart = &now
-	if err := db.Model(&game).UpdateColumn("current_turn_start", game.CurrentTurnStart).Error; err != nil {
+	applied, err := updateGameVersioned(db, &game, pre, map[string]interface{}{
+		"current_turn_start": game.CurrentTurnStart,
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить игру"})
 		return
 	}
-	db.Preload("Players.User").Preload("Turns").First(&game, game.ID)
-	c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+	if !applied {
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
+	db.Scopes(withGameAssociations).First(&game, game.ID)
+	respondGame(c, http.StatusOK, game)
 }
 
-// GetActiveGame — текущая активная игра (end_time IS NULL); 404 если нет.
+// GetActiveGame — текущая активная игра (end_time IS NULL); 404 если нет. Заголовок ETag — для If-Match.
 func GetActiveGame(c *gin.Context) {
 	db := database.GetDB()
 	var game models.Game
-	if err := db.Where("end_time IS NULL").Preload("Players.User").Preload("Turns").First(&game).Error; err != nil {
+	if err := db.Where("end_time IS NULL").Scopes(withGameAssociations).First(&game).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Нет активной игры"})
 		return
 	}
-	c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+	respondGame(c, http.StatusOK, game)
 }
 
 // UpdateActiveGame — обновление текущего хода и списка ходов активной игры; 404 если нет активной.
+// If-Match или expected_version: при несовпадении версии — 412 с актуальным состоянием игры.
 func UpdateActiveGame(c *gin.Context) {
 	var req models.UpdateActiveGameRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	pre, ok := readGamePrecondition(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
 
 	db := database.GetDB()
 	var game models.Game
@@ -421,37 +553,57 @@ func UpdateActiveGame(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Нет активной игры"})
 		return
 	}
+	if !pre.matches(game) {
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
 
+	prevTurnStart := game.CurrentTurnStart
+	now := time.Now().UTC()
 	game.CurrentTurnTeam = req.CurrentTurnTeam
 	// Используем серверное время для начала хода — так таймер сохранится при перезагрузке страницы.
 	if req.CurrentTurnStart != nil && req.CurrentTurnStart.T != nil {
-		now := time.Now().UTC()
 		game.CurrentTurnStart = &now
 	} else {
 		game.CurrentTurnStart = nil
 	}
-	// UpdateColumn гарантирует запись в БД (Updates с map иногда пропускает *time.Time).
-	if err := db.Model(&game).UpdateColumn("current_turn_team", game.CurrentTurnTeam).Error; err != nil {
+
+	tx := db.Begin()
+	if tx.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить игру"})
 		return
 	}
-	if err := db.Model(&game).UpdateColumn("current_turn_start", game.CurrentTurnStart).Error; err != nil {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Условный UPDATE по версии — вторая запись с тем же If-Match получит 412, а не перезапишет ходы.
+	applied, err := updateGameVersioned(tx, &game, pre, map[string]interface{}{
+		"current_turn_team":  game.CurrentTurnTeam,
+		"current_turn_start": game.CurrentTurnStart,
+	})
+	if err != nil {
+		tx.Rollback()
+		log.Printf("UpdateActiveGame: update game: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить игру"})
 		return
 	}
+	if !applied {
+		tx.Rollback()
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
 
 	if len(req.Turns) > 0 {
-		tx := db.Begin()
-		if tx.Error != nil {
+		var existingTurns []models.GameTurn
+		if err := tx.Where("game_id = ?", game.ID).Order("id ASC").Find(&existingTurns).Error; err != nil {
+			tx.Rollback()
+			log.Printf("UpdateActiveGame: load turns: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить ходы"})
 			return
 		}
-		defer func() {
-			if r := recover(); r != nil {
-				tx.Rollback()
-			}
-		}()
-
 		if err := tx.Where("game_id = ?", game.ID).Delete(&models.GameTurn{}).Error; err != nil {
 			tx.Rollback()
 			log.Printf("UpdateActiveGame: delete turns: %v", err)
@@ -461,15 +613,7 @@ func UpdateActiveGame(c *gin.Context) {
 		if err := tx.Exec("SELECT setval(pg_get_serial_sequence('game_turns', 'id'), COALESCE((SELECT MAX(id) FROM game_turns), 0))").Error; err != nil {
 			log.Printf("UpdateActiveGame: reset sequence (ignored): %v", err)
 		}
-		turnsToCreate := make([]models.GameTurn, len(req.Turns))
-		for i := range req.Turns {
-			turnsToCreate[i] = models.GameTurn{
-				GameID:     game.ID,
-				TeamNumber: req.Turns[i].TeamNumber,
-				Duration:   req.Turns[i].Duration,
-				Overtime:   req.Turns[i].Overtime,
-			}
-		}
+		turnsToCreate := stampTurnTimes(game.ID, req.Turns, existingTurns, prevTurnStart, now)
 		if err := tx.Omit("ID").Create(&turnsToCreate).Error; err != nil {
 			tx.Rollback()
 			log.Printf("UpdateActiveGame: create turns: %v", err)
@@ -479,19 +623,19 @@ func UpdateActiveGame(c *gin.Context) {
 			})
 			return
 		}
-		if err := tx.Commit().Error; err != nil {
-			log.Printf("UpdateActiveGame: commit: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить ходы"})
-			return
-		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("UpdateActiveGame: commit: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить ходы"})
+		return
 	}
 	invalidateStatsCache()
 
-	db.Preload("Players.User").Preload("Turns").First(&game, game.ID)
-	c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+	db.Scopes(withGameAssociations).First(&game, game.ID)
+	respondGame(c, http.StatusOK, game)
 }
 
-// FinishGame — завершение активной игры; winning_team 1 или 2; 404 если нет активной.
+// FinishGame — завершение активной игры; winning_team 1 или 2; 404 если нет активной; 412 при устаревшей версии.
 func FinishGame(c *gin.Context) {
 	var req models.FinishGameRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -502,6 +646,10 @@ func FinishGame(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "winning_team должен быть 1 или 2"})
 		return
 	}
+	pre, ok := readGamePrecondition(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
 
 	db := database.GetDB()
 	var game models.Game
@@ -509,26 +657,93 @@ func FinishGame(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Нет активной игры"})
 		return
 	}
+	if !pre.matches(game) {
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
 
 	now := time.Now().UTC()
 	game.EndTime = &now
 	game.WinningTeam = &req.WinningTeam
 	game.IsTechnicalDefeat = req.IsTechnicalDefeat
-	if err := db.Model(&game).Updates(map[string]interface{}{
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось завершить игру"})
+		return
+	}
+	updates := map[string]interface{}{
 		"end_time":            game.EndTime,
 		"winning_team":        game.WinningTeam,
 		"is_technical_defeat": game.IsTechnicalDefeat,
-	}).Error; err != nil {
+	}
+	// Завершение во время паузы закрывает открытый интервал, чтобы время партии считалось без неё.
+	if game.IsPaused {
+		totalPause, err := closeOpenPauses(tx, game, now)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("FinishGame: close pauses: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось завершить игру"})
+			return
+		}
+		updates["is_paused"] = false
+		updates["pause_started_at"] = nil
+		updates["total_pause_duration_seconds"] = totalPause
+	}
+	applied, err := updateGameVersioned(tx, &game, pre, updates)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось завершить игру"})
+		return
+	}
+	if !applied {
+		tx.Rollback()
+		writeGamePreconditionFailed(c, db, game.ID)
+		return
+	}
+	if err := applyGameRatings(tx, game.ID); err != nil {
+		tx.Rollback()
+		log.Printf("FinishGame: ratings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить рейтинги"})
+		return
+	}
+	if err := applyGameAggregates(tx, game.ID); err != nil {
+		tx.Rollback()
+		log.Printf("FinishGame: stats aggregates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить статистику"})
+		return
+	}
+	if err := applyGameAchievements(tx, game.ID); err != nil {
+		tx.Rollback()
+		log.Printf("FinishGame: achievements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить достижения"})
+		return
+	}
+	if game.MatchID != nil {
+		if err := recomputeMatchResult(tx, *game.MatchID); err != nil {
+			tx.Rollback()
+			log.Printf("FinishGame: match: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить счёт матча"})
+			return
+		}
+	}
+	if err := applyTournamentGame(tx, game.ID); err != nil {
+		tx.Rollback()
+		log.Printf("FinishGame: tournament: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить матч турнира"})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось завершить игру"})
 		return
 	}
 	invalidateStatsCache()
 
-	db.Preload("Players.User").Preload("Turns").First(&game, game.ID)
-	c.JSON(http.StatusOK, gameResponse(game, gameViewer(c)))
+	db.Scopes(withGameAssociations).First(&game, game.ID)
+	respondGame(c, http.StatusOK, game)
 }
 
-// ClearGamesAndTurns — полная очистка таблиц games, game_players и game_turns.
+// ClearGamesAndTurns — полная очистка таблиц games, game_players, game_turns и game_pauses.
 func ClearGamesAndTurns(c *gin.Context) {
 	db := database.GetDB()
 
@@ -538,6 +753,56 @@ func ClearGamesAndTurns(c *gin.Context) {
 		return
 	}
 
+	if err := tx.Exec("DELETE FROM player_rating_history").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить историю рейтингов"})
+		return
+	}
+	if err := tx.Exec("DELETE FROM player_ratings").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить рейтинги"})
+		return
+	}
+	if err := tx.Exec("DELETE FROM deck_ratings").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить рейтинги колод"})
+		return
+	}
+	if err := clearStatsAggregates(tx); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить агрегаты статистики"})
+		return
+	}
+	if err := tx.Exec("DELETE FROM user_achievements").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить достижения"})
+		return
+	}
+	if err := tx.Exec("DELETE FROM leaderboard_entries").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить строки снимков таблицы лидеров"})
+		return
+	}
+	if err := tx.Exec("DELETE FROM leaderboard_snapshots").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить снимки таблицы лидеров"})
+		return
+	}
+	if err := tx.Exec("DELETE FROM matches").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить матчи"})
+		return
+	}
+	if err := tx.Exec("UPDATE tournament_matches SET game_id = NULL").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось отвязать матчи турниров"})
+		return
+	}
+	if err := tx.Exec("DELETE FROM game_pauses").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить паузы игр"})
+		return
+	}
 	if err := tx.Exec("DELETE FROM game_turns").Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось очистить ходы игр"})
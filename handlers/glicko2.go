@@ -0,0 +1,138 @@
+package handlers
+
+import "math"
+
+// Параметры Glicko-2 (Glickman, "Example of the Glicko-2 system").
+const (
+	glickoDefaultRating     = 1500.0
+	glickoDefaultDeviation  = 350.0
+	glickoDefaultVolatility = 0.06
+	glickoTau               = 0.5
+	glickoScale             = 173.7178
+	glickoEpsilon           = 0.000001
+)
+
+// glickoRating — рейтинг в шкале Glicko (1500 ± deviation).
+type glickoRating struct {
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+}
+
+func newGlickoRating() glickoRating {
+	return glickoRating{
+		Rating:     glickoDefaultRating,
+		Deviation:  glickoDefaultDeviation,
+		Volatility: glickoDefaultVolatility,
+	}
+}
+
+// glickoResult — результат против соперника; score 1 — победа, 0 — поражение.
+type glickoResult struct {
+	Rating    float64
+	Deviation float64
+	Score     float64
+}
+
+// teamComposite — команда как один соперник: средний рейтинг и среднеквадратичная deviation.
+func teamComposite(members []glickoRating) glickoRating {
+	if len(members) == 0 {
+		return newGlickoRating()
+	}
+	var sumRating, sumVar float64
+	for _, m := range members {
+		sumRating += m.Rating
+		sumVar += m.Deviation * m.Deviation
+	}
+	n := float64(len(members))
+	return glickoRating{
+		Rating:     sumRating / n,
+		Deviation:  math.Sqrt(sumVar / n),
+		Volatility: glickoDefaultVolatility,
+	}
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glickoE(mu, muJ, phiJ float64) float64 {
+	return 1 / (1 + math.Exp(-glickoG(phiJ)*(mu-muJ)))
+}
+
+// expectedScore — ожидаемый результат r против соперника (0..1).
+func (r glickoRating) expectedScore(opp glickoRating) float64 {
+	mu := (r.Rating - glickoDefaultRating) / glickoScale
+	muJ := (opp.Rating - glickoDefaultRating) / glickoScale
+	return glickoE(mu, muJ, opp.Deviation/glickoScale)
+}
+
+// update — один рейтинговый период Glicko-2; без результатов растёт только deviation.
+func (r glickoRating) update(results []glickoResult) glickoRating {
+	mu := (r.Rating - glickoDefaultRating) / glickoScale
+	phi := r.Deviation / glickoScale
+	sigma := r.Volatility
+
+	if len(results) == 0 {
+		phiStar := math.Sqrt(phi*phi + sigma*sigma)
+		return glickoRating{
+			Rating:     r.Rating,
+			Deviation:  math.Min(phiStar*glickoScale, glickoDefaultDeviation),
+			Volatility: sigma,
+		}
+	}
+
+	var vInv, deltaSum float64
+	for _, res := range results {
+		muJ := (res.Rating - glickoDefaultRating) / glickoScale
+		phiJ := res.Deviation / glickoScale
+		g := glickoG(phiJ)
+		e := glickoE(mu, muJ, phiJ)
+		vInv += g * g * e * (1 - e)
+		deltaSum += g * (res.Score - e)
+	}
+	v := 1 / vInv
+	delta := v * deltaSum
+
+	// Новая волатильность — итерацией Illinois (шаг 5 алгоритма).
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		num := ex * (delta*delta - phi*phi - v - ex)
+		den := 2 * (phi*phi + v + ex) * (phi*phi + v + ex)
+		return num/den - (x-a)/(glickoTau*glickoTau)
+	}
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		B = a - k*glickoTau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glickoEpsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	newSigma := math.Exp(A / 2)
+
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*deltaSum
+
+	return glickoRating{
+		Rating:     newMu*glickoScale + glickoDefaultRating,
+		Deviation:  math.Min(newPhi*glickoScale, glickoDefaultDeviation),
+		Volatility: newSigma,
+	}
+}
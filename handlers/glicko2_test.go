@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGlickoUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		player  glickoRating
+		results []glickoResult
+		want    glickoRating
+	}{
+		{
+			// Пример из Glickman, "Example of the Glicko-2 system".
+			name:   "worked example",
+			player: glickoRating{Rating: 1500, Deviation: 200, Volatility: 0.06},
+			results: []glickoResult{
+				{Rating: 1400, Deviation: 30, Score: 1},
+				{Rating: 1550, Deviation: 100, Score: 0},
+				{Rating: 1700, Deviation: 300, Score: 0},
+			},
+			want: glickoRating{Rating: 1464.06, Deviation: 151.52, Volatility: 0.05999},
+		},
+		{
+			name:   "no games only grows deviation",
+			player: glickoRating{Rating: 1500, Deviation: 200, Volatility: 0.06},
+			want:   glickoRating{Rating: 1500, Deviation: 200.27, Volatility: 0.06},
+		},
+		{
+			name:   "deviation is capped at default",
+			player: newGlickoRating(),
+			want:   newGlickoRating(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.player.update(tt.results)
+			if math.Abs(got.Rating-tt.want.Rating) > 0.01 ||
+				math.Abs(got.Deviation-tt.want.Deviation) > 0.01 ||
+				math.Abs(got.Volatility-tt.want.Volatility) > 0.00001 {
+				t.Errorf("update = %.4f/%.4f/%.6f, want %.2f/%.2f/%.5f",
+					got.Rating, got.Deviation, got.Volatility,
+					tt.want.Rating, tt.want.Deviation, tt.want.Volatility)
+			}
+		})
+	}
+}
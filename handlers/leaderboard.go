@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// leaderboardWinRateMinGames — минимум игр для места в таблице по проценту побед.
+const leaderboardWinRateMinGames = 3
+
+var leaderboardMetrics = []string{
+	models.LeaderboardMetricWinRate,
+	models.LeaderboardMetricRating,
+	models.LeaderboardMetricGamesPlayed,
+}
+
+// parseLeaderboardMetric — параметр metric (по умолчанию win_rate); при ошибке пишет 400.
+func parseLeaderboardMetric(c *gin.Context) (string, bool) {
+	metric := c.DefaultQuery("metric", models.LeaderboardMetricWinRate)
+	for _, m := range leaderboardMetrics {
+		if m == metric {
+			return metric, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный metric (win_rate, rating, games_played)"})
+	return "", false
+}
+
+// computeLeaderboard — текущая таблица по метрике по всем завершённым играм.
+// Порядок: значение, число игр, имя; игроки с равным значением делят место.
+func computeLeaderboard(db *gorm.DB, metric string) ([]models.LeaderboardEntry, error) {
+	type leaderboardRow struct {
+		UserID     uint    `gorm:"column:user_id"`
+		PlayerName string  `gorm:"column:player_name"`
+		GamesCount int     `gorm:"column:games_count"`
+		WinsCount  int     `gorm:"column:wins_count"`
+		Rating     float64 `gorm:"column:rating"`
+	}
+	var rows []leaderboardRow
+	if metric == models.LeaderboardMetricRating {
+		if err := db.Raw(`
+			SELECT pr.user_id, u.name AS player_name, pr.games_count, pr.rating
+			FROM player_ratings pr
+			JOIN users u ON u.id = pr.user_id
+			WHERE pr.games_count > 0
+		`).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		whereClause, whereArgs := buildCompletedGamesWhereClause("g", statsFilter{IncludeTechnical: true})
+		query := fmt.Sprintf(`
+			WITH %s
+			SELECT
+				user_id,
+				MAX(player_name) AS player_name,
+				COUNT(*) AS games_count,
+				SUM(CASE WHEN player_team = winning_team THEN 1 ELSE 0 END) AS wins_count
+			FROM players_with_team
+			GROUP BY user_id
+		`, playersWithTeamCTE(whereClause))
+		if err := db.Raw(query, whereArgs...).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]models.LeaderboardEntry, 0, len(rows))
+	for _, r := range rows {
+		entry := models.LeaderboardEntry{
+			Metric:     metric,
+			UserID:     r.UserID,
+			PlayerName: r.PlayerName,
+			GamesCount: r.GamesCount,
+		}
+		switch metric {
+		case models.LeaderboardMetricWinRate:
+			if r.GamesCount < leaderboardWinRateMinGames {
+				continue
+			}
+			entry.Value = float64(r.WinsCount) / float64(r.GamesCount) * 100
+		case models.LeaderboardMetricRating:
+			entry.Value = r.Rating
+		case models.LeaderboardMetricGamesPlayed:
+			entry.Value = float64(r.GamesCount)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Value != b.Value {
+			return a.Value > b.Value
+		}
+		if a.GamesCount != b.GamesCount {
+			return a.GamesCount > b.GamesCount
+		}
+		return a.PlayerName < b.PlayerName
+	})
+	for i := range entries {
+		if i > 0 && entries[i].Value == entries[i-1].Value {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+	return entries, nil
+}
+
+// TakeLeaderboardSnapshot — снимок текущих таблиц по всем метрикам.
+func TakeLeaderboardSnapshot(db *gorm.DB) (models.LeaderboardSnapshot, error) {
+	snapshot := models.LeaderboardSnapshot{TakenAt: time.Now().UTC()}
+	for _, metric := range leaderboardMetrics {
+		entries, err := computeLeaderboard(db, metric)
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.Entries = append(snapshot.Entries, entries...)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&snapshot).Error
+	})
+	return snapshot, err
+}
+
+// StartLeaderboardSnapshots — периодические снимки в фоне: раз в час проверяется, не старше ли последний снимок
+// LEADERBOARD_SNAPSHOT_INTERVAL_HOURS (по умолчанию 168 — неделя); если старше или снимков нет — делается новый.
+func StartLeaderboardSnapshots(db *gorm.DB) {
+	interval := time.Duration(envInt("LEADERBOARD_SNAPSHOT_INTERVAL_HOURS", 168)) * time.Hour
+	check := func() {
+		var last models.LeaderboardSnapshot
+		err := db.Order("taken_at DESC").First(&last).Error
+		if err == nil && time.Since(last.TakenAt) < interval {
+			return
+		}
+		snapshot, err := TakeLeaderboardSnapshot(db)
+		if err != nil {
+			log.Printf("Снимок таблицы лидеров не сделан: %v", err)
+			return
+		}
+		invalidateStatsCache()
+		log.Printf("Снимок таблицы лидеров #%d сделан", snapshot.ID)
+	}
+	go func() {
+		check()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			check()
+		}
+	}()
+}
+
+func leaderboardSnapshotInLocation(s models.LeaderboardSnapshot, loc *time.Location) models.LeaderboardSnapshot {
+	s.TakenAt = inLocation(s.TakenAt, loc)
+	return s
+}
+
+// GetLeaderboard — текущая таблица по metric с движением относительно снимка snapshot_id
+// (по умолчанию — последнего). Поддерживает limit.
+func GetLeaderboard(c *gin.Context) {
+	metric, ok := parseLeaderboardMetric(c)
+	if !ok {
+		return
+	}
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return
+		}
+		limit = v
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	var snapshot models.LeaderboardSnapshot
+	hasSnapshot := true
+	if raw := c.Query("snapshot_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный snapshot_id"})
+			return
+		}
+		if err := db.First(&snapshot, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Снимок не найден"})
+			return
+		}
+	} else if err := db.Order("taken_at DESC, id DESC").First(&snapshot).Error; err != nil {
+		hasSnapshot = false
+	}
+
+	current, err := computeLeaderboard(db, metric)
+	if err != nil {
+		log.Printf("GetLeaderboard: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать таблицу лидеров"})
+		return
+	}
+	previous := make(map[uint]int)
+	if hasSnapshot {
+		var entries []models.LeaderboardEntry
+		if err := db.Where("snapshot_id = ? AND metric = ?", snapshot.ID, metric).Find(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить снимок таблицы лидеров"})
+			return
+		}
+		for _, e := range entries {
+			previous[e.UserID] = e.Rank
+		}
+	}
+
+	if limit > 0 && len(current) > limit {
+		current = current[:limit]
+	}
+	resp := models.LeaderboardResponse{Metric: metric, Entries: make([]models.LeaderboardRow, 0, len(current))}
+	if hasSnapshot {
+		_, loc, _ := resolveConfiguredTimezone()
+		compared := leaderboardSnapshotInLocation(snapshot, loc)
+		resp.ComparedTo = &compared
+	}
+	for _, e := range current {
+		row := models.LeaderboardRow{
+			Rank:       e.Rank,
+			UserID:     e.UserID,
+			PlayerName: e.PlayerName,
+			Value:      e.Value,
+			GamesCount: e.GamesCount,
+		}
+		if prev, ok := previous[e.UserID]; ok {
+			delta := prev - e.Rank
+			row.PreviousRank, row.RankDelta = &prev, &delta
+		} else if hasSnapshot {
+			row.IsNew = true
+		}
+		resp.Entries = append(resp.Entries, row)
+	}
+	writeStatsCacheJSON(c, resp)
+}
+
+// GetLeaderboardSnapshots — список снимков без строк, новые сначала.
+func GetLeaderboardSnapshots(c *gin.Context) {
+	var snapshots []models.LeaderboardSnapshot
+	if err := database.GetDB().Order("taken_at DESC, id DESC").Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список снимков"})
+		return
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	for i := range snapshots {
+		snapshots[i] = leaderboardSnapshotInLocation(snapshots[i], loc)
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+// CreateLeaderboardSnapshot — внеочередной снимок таблицы лидеров; только администратор.
+func CreateLeaderboardSnapshot(c *gin.Context) {
+	snapshot, err := TakeLeaderboardSnapshot(database.GetDB())
+	if err != nil {
+		log.Printf("CreateLeaderboardSnapshot: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сделать снимок таблицы лидеров"})
+		return
+	}
+	invalidateStatsCache()
+	_, loc, _ := resolveConfiguredTimezone()
+	c.JSON(http.StatusCreated, leaderboardSnapshotInLocation(snapshot, loc))
+}
+
+// GetLeaderboardHistory — места игрока по снимкам для metric, старые сначала; снимки без игрока пропускаются.
+func GetLeaderboardHistory(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+	metric, ok := parseLeaderboardMetric(c)
+	if !ok {
+		return
+	}
+	db := database.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+	var points []models.LeaderboardRankPoint
+	if err := db.Raw(`
+		SELECT s.id AS snapshot_id, s.taken_at, e.rank, e.value, e.games_count
+		FROM leaderboard_entries e
+		JOIN leaderboard_snapshots s ON s.id = e.snapshot_id
+		WHERE e.user_id = ? AND e.metric = ?
+		ORDER BY s.taken_at ASC, s.id ASC
+	`, userID, metric).Scan(&points).Error; err != nil {
+		log.Printf("GetLeaderboardHistory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить историю мест"})
+		return
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	for i := range points {
+		points[i].TakenAt = inLocation(points[i].TakenAt, loc)
+	}
+	if points == nil {
+		points = []models.LeaderboardRankPoint{}
+	}
+	c.JSON(http.StatusOK, models.LeaderboardHistoryResponse{UserID: user.ID, Metric: metric, Points: points})
+}
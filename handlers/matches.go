@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultMatchBestOf = 3
+
+var (
+	errMatchActiveGame     = errors.New("Активная игра уже существует")
+	errMatchFinished       = errors.New("Матч уже завершён")
+	errMatchNextGameExists = errors.New("Следующая партия матча уже создана")
+)
+
+// matchWinsNeeded — побед в партиях для победы в матче (best_of 3 → 2, best_of 5 → 3).
+func matchWinsNeeded(bestOf int) int {
+	return bestOf/2 + 1
+}
+
+// recomputeMatchResult — счёт матча по его завершённым партиям; победитель — сторона, набравшая best_of/2+1 побед.
+func recomputeMatchResult(tx *gorm.DB, matchID uint) error {
+	var match models.Match
+	if err := tx.First(&match, matchID).Error; err != nil {
+		return err
+	}
+	var games []models.Game
+	if err := tx.Where("match_id = ? AND end_time IS NOT NULL AND winning_team IS NOT NULL", matchID).
+		Order("start_time ASC, id ASC").Find(&games).Error; err != nil {
+		return err
+	}
+	match.Team1Wins, match.Team2Wins, match.WinningTeam = 0, 0, nil
+	need := matchWinsNeeded(match.BestOf)
+	for _, g := range games {
+		if *g.WinningTeam == 1 {
+			match.Team1Wins++
+		} else {
+			match.Team2Wins++
+		}
+		if match.WinningTeam == nil && (match.Team1Wins >= need || match.Team2Wins >= need) {
+			team := *g.WinningTeam
+			match.WinningTeam = &team
+		}
+	}
+	return tx.Model(&match).Select("team1_wins", "team2_wins", "winning_team").Updates(&match).Error
+}
+
+// matchRecord — матчи и победы в матчах игрока или колоды.
+type matchRecord struct {
+	Key          int `gorm:"column:key"`
+	MatchesCount int `gorm:"column:matches_count"`
+	MatchWins    int `gorm:"column:match_wins"`
+}
+
+func (r matchRecord) winPercent() float64 {
+	return winPercent(r.MatchWins, r.MatchesCount)
+}
+
+// loadMatchRecords — итоги решённых матчей по column (user_id или deck_id) среди партий, отобранных where.
+// Участник матча — участник хотя бы одной его партии; сторона — его команда в этих партиях.
+func loadMatchRecords(db *gorm.DB, column, where string, whereArgs []interface{}) (map[int]matchRecord, error) {
+	query := fmt.Sprintf(`
+		WITH %s,
+		match_sides AS (
+			SELECT DISTINCT g.match_id, pwt.%s AS key, pwt.player_team
+			FROM players_with_team pwt
+			JOIN games g ON g.id = pwt.game_id
+			WHERE g.match_id IS NOT NULL
+		)
+		SELECT
+			ms.key,
+			COUNT(*) AS matches_count,
+			SUM(CASE WHEN ms.player_team = m.winning_team THEN 1 ELSE 0 END) AS match_wins
+		FROM match_sides ms
+		JOIN matches m ON m.id = ms.match_id
+		WHERE m.winning_team IS NOT NULL
+		GROUP BY ms.key
+	`, playersWithTeamCTE(where), column)
+	var rows []matchRecord
+	if err := db.Raw(query, whereArgs...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]matchRecord, len(rows))
+	for _, r := range rows {
+		out[r.Key] = r
+	}
+	return out, nil
+}
+
+func matchResponse(match models.Match, games []models.Game, c *gin.Context) models.MatchResponse {
+	_, loc, _ := resolveConfiguredTimezone()
+	match.CreatedAt = inLocation(match.CreatedAt, loc)
+	match.UpdatedAt = inLocation(match.UpdatedAt, loc)
+	resp := models.MatchResponse{
+		Match:   match,
+		Players: []models.GamePlayerResponse{},
+		Games:   make([]models.MatchGameSummary, 0, len(games)),
+	}
+	if len(games) > 0 {
+		first := gameToResponse(games[0], gameViewer(c), loc)
+		resp.Team1Name, resp.Team2Name, resp.Players = first.Team1Name, first.Team2Name, first.Players
+	}
+	for i, g := range games {
+		resp.Games = append(resp.Games, models.MatchGameSummary{
+			GameID:            g.ID,
+			GameNumber:        i + 1,
+			StartTime:         inLocation(g.StartTime, loc),
+			EndTime:           inLocationPtr(g.EndTime, loc),
+			FirstMoveTeam:     g.FirstMoveTeam,
+			WinningTeam:       g.WinningTeam,
+			IsTechnicalDefeat: g.IsTechnicalDefeat,
+		})
+	}
+	return resp
+}
+
+// GetMatches — список матчей, новые сначала.
+func GetMatches(c *gin.Context) {
+	db := database.GetDB()
+	var matches []models.Match
+	if err := db.Order("id DESC").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список матчей"})
+		return
+	}
+	ids := make([]uint, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	var games []models.Game
+	if len(ids) > 0 {
+		if err := db.Scopes(withGameAssociations).Where("match_id IN ?", ids).Order("start_time ASC, id ASC").Find(&games).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить партии матчей"})
+			return
+		}
+	}
+	byMatch := make(map[uint][]models.Game, len(matches))
+	for _, g := range games {
+		byMatch[*g.MatchID] = append(byMatch[*g.MatchID], g)
+	}
+	resp := make([]models.MatchResponse, len(matches))
+	for i, m := range matches {
+		resp[i] = matchResponse(m, byMatch[m.ID], c)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetMatch — матч по id со сторонами и партиями; 404 если не найден.
+func GetMatch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID матча"})
+		return
+	}
+	db := database.GetDB()
+	var match models.Match
+	if err := db.First(&match, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Матч не найден"})
+		return
+	}
+	var games []models.Game
+	if err := db.Scopes(withGameAssociations).Where("match_id = ?", match.ID).Order("start_time ASC, id ASC").Find(&games).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить партии матча"})
+		return
+	}
+	c.JSON(http.StatusOK, matchResponse(match, games, c))
+}
+
+// CreateNextMatchGame — следующая партия матча по завершённой игре: те же игроки, команды и колоды,
+// первый ход — у проигравшей в предыдущей партии стороны. Партия без матча становится первой партией
+// нового матча best_of. 409, если матч уже решён или есть активная игра.
+func CreateNextMatchGame(c *gin.Context) {
+	var req models.NextMatchGameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SourceGameID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source_game_id обязателен"})
+		return
+	}
+	if req.BestOf == 0 {
+		req.BestOf = defaultMatchBestOf
+	}
+	if req.BestOf != 3 && req.BestOf != 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "best_of должен быть 3 или 5"})
+		return
+	}
+
+	db := database.GetDB()
+	var source models.Game
+	if err := db.Scopes(withGameAssociations).First(&source, req.SourceGameID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Исходная игра не найдена"})
+		return
+	}
+	if source.EndTime == nil || source.WinningTeam == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Следующая партия возможна только после завершённой игры"})
+		return
+	}
+	if len(source.Players) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недостаточно игроков для матча"})
+		return
+	}
+
+	token, err := uniqueViewToken(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сгенерировать публичный токен"})
+		return
+	}
+
+	// Команды определяются порядком добавления игроков — сохраняем его, чтобы стороны матча не поменялись.
+	players := make([]models.GamePlayer, 0, len(source.Players))
+	for _, p := range sortedGamePlayers(source.Players) {
+		players = append(players, models.GamePlayer{
+			UserID:   p.UserID,
+			User:     models.User{ID: p.User.ID, Name: p.User.Name, IsAdmin: p.User.IsAdmin},
+			DeckID:   p.DeckID,
+			DeckName: p.DeckName,
+		})
+	}
+	firstMoveTeam := 3 - *source.WinningTeam
+	now := time.Now().UTC()
+	next := models.Game{
+		ViewToken:            token,
+		StartTime:            now,
+		TurnLimitSeconds:     source.TurnLimitSeconds,
+		TeamTimeLimitSeconds: source.TeamTimeLimitSeconds,
+		FirstMoveTeam:        firstMoveTeam,
+		Team1Name:            source.Team1Name,
+		Team2Name:            source.Team2Name,
+		CurrentTurnTeam:      firstMoveTeam,
+		Players:              players,
+		Turns:                []models.GameTurn{},
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+
+	// Проверки — под блокировкой исходной игры и матча: повторный запрос дождётся первого
+	// и не создаст вторую следующую партию.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var locked models.Game
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "match_id").First(&locked, source.ID).Error; err != nil {
+			return err
+		}
+		if locked.MatchID != nil {
+			var match models.Match
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&match, *locked.MatchID).Error; err != nil {
+				return err
+			}
+			if match.WinningTeam != nil {
+				return errMatchFinished
+			}
+			var later int64
+			if err := tx.Model(&models.Game{}).Where("match_id = ? AND id > ?", match.ID, source.ID).Count(&later).Error; err != nil {
+				return err
+			}
+			if later > 0 {
+				return errMatchNextGameExists
+			}
+		}
+		var active int64
+		if err := tx.Model(&models.Game{}).Where("end_time IS NULL").Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return errMatchActiveGame
+		}
+
+		next.MatchID = locked.MatchID
+		if next.MatchID == nil {
+			match := models.Match{BestOf: req.BestOf}
+			if err := tx.Create(&match).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Game{}).Where("id = ?", source.ID).Update("match_id", match.ID).Error; err != nil {
+				return err
+			}
+			if err := recomputeMatchResult(tx, match.ID); err != nil {
+				return err
+			}
+			next.MatchID = &match.ID
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Create(&next).Error
+	})
+	if errors.Is(err, errMatchActiveGame) || errors.Is(err, errMatchFinished) || errors.Is(err, errMatchNextGameExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Матч не найден"})
+		return
+	}
+	if err != nil {
+		log.Printf("CreateNextMatchGame: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать партию матча"})
+		return
+	}
+	invalidateStatsCache()
+	db.Scopes(withGameAssociations).First(&next, next.ID)
+	respondGame(c, http.StatusCreated, next)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"time"
+
+	"mtg-stats-backend/middleware"
+	"mtg-stats-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// withGameAssociations — scope загрузки игры для ответа: игроки с пользователями, ходы и паузы по времени.
+func withGameAssociations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Players.User").Preload("Turns").Preload("Pauses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("started_at ASC, id ASC")
+	})
+}
+
+// closedPauseSeconds — суммарная длительность завершённых пауз (сек).
+func closedPauseSeconds(pauses []models.GamePause) int {
+	var total time.Duration
+	for _, p := range pauses {
+		if p.EndedAt != nil && p.EndedAt.After(p.StartedAt) {
+			total += p.EndedAt.Sub(p.StartedAt)
+		}
+	}
+	return int(total.Seconds())
+}
+
+// gamePauseSeconds — общее время пауз партии по интервалам game_pauses.
+// Накопленный счётчик включает и паузы, поставленные до появления game_pauses, поэтому берётся большее из двух.
+func gamePauseSeconds(g models.Game) int {
+	closed := closedPauseSeconds(g.Pauses)
+	if g.TotalPauseDurationSeconds > closed {
+		return g.TotalPauseDurationSeconds
+	}
+	return closed
+}
+
+// pauseAdjustedTurnStart — начало текущего хода, сдвинутое на завершённые паузы внутри хода.
+// В БД хранится фактическое время начала хода; клиенту отдаётся сдвинутое, чтобы таймер не учитывал паузы.
+func pauseAdjustedTurnStart(start *time.Time, pauses []models.GamePause) *time.Time {
+	if start == nil {
+		return nil
+	}
+	var shift time.Duration
+	for _, p := range pauses {
+		if p.EndedAt == nil || !p.EndedAt.After(*start) {
+			continue
+		}
+		from := p.StartedAt
+		if from.Before(*start) {
+			from = *start
+		}
+		shift += p.EndedAt.Sub(from)
+	}
+	adjusted := start.Add(shift)
+	return &adjusted
+}
+
+// newGamePause — открытая пауза от имени текущего пользователя (API_TOKEN — без автора).
+func newGamePause(gameID uint, startedAt time.Time, reason string, actor *middleware.UserInfo) models.GamePause {
+	pause := models.GamePause{
+		GameID:    gameID,
+		StartedAt: startedAt,
+		Reason:    reason,
+	}
+	if actor != nil && actor.ID != 0 {
+		id := actor.ID
+		pause.ActorUserID = &id
+		pause.ActorName = actor.Name
+	}
+	return pause
+}
+
+// closeOpenPauses закрывает открытые паузы игры и возвращает новое общее время пауз.
+// Если игра на паузе, но открытого интервала нет (пауза поставлена до миграции) — интервал создаётся по pause_started_at.
+// Время пауз до миграции, не покрытое интервалами, сохраняется в общем времени.
+func closeOpenPauses(tx *gorm.DB, game models.Game, now time.Time) (int, error) {
+	var pauses []models.GamePause
+	if err := tx.Where("game_id = ?", game.ID).Find(&pauses).Error; err != nil {
+		return 0, err
+	}
+	legacy := game.TotalPauseDurationSeconds - closedPauseSeconds(pauses)
+	if legacy < 0 {
+		legacy = 0
+	}
+	res := tx.Model(&models.GamePause{}).
+		Where("game_id = ? AND ended_at IS NULL", game.ID).
+		Update("ended_at", now)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 && game.IsPaused && game.PauseStartedAt != nil {
+		pause := models.GamePause{GameID: game.ID, StartedAt: *game.PauseStartedAt, EndedAt: &now}
+		if err := tx.Create(&pause).Error; err != nil {
+			return 0, err
+		}
+	}
+	pauses = nil
+	if err := tx.Where("game_id = ?", game.ID).Find(&pauses).Error; err != nil {
+		return 0, err
+	}
+	return legacy + closedPauseSeconds(pauses), nil
+}
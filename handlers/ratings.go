@@ -0,0 +1,494 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// playerTeam — команда игрока по позиции в игре (по возрастанию id): первая половина — 1, вторая — 2.
+// Совпадает с выводом команд в SQL статистики (player_index <= players_count / 2).
+func playerTeam(index, count int) int {
+	if index < count/2 {
+		return 1
+	}
+	return 2
+}
+
+// sortedGamePlayers — игроки партии в порядке добавления (id), от которого зависит деление на команды.
+func sortedGamePlayers(players []models.GamePlayer) []models.GamePlayer {
+	out := append([]models.GamePlayer(nil), players...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// ratingEngine — последовательный пересчёт рейтингов Glicko-2 по играм.
+// Каждая игра — отдельный рейтинговый период; соперник игрока — усреднённая команда противников.
+// Параллельно ведутся рейтинги колод с поправкой на силу пилотов (см. deckResults).
+type ratingEngine struct {
+	players    map[uint]glickoRating
+	gamesCount map[uint]int
+	lastGameAt map[uint]time.Time
+
+	decks          map[int]glickoRating
+	deckGamesCount map[int]int
+	deckLastGameAt map[int]time.Time
+}
+
+func newRatingEngine() *ratingEngine {
+	return &ratingEngine{
+		players:        make(map[uint]glickoRating),
+		gamesCount:     make(map[uint]int),
+		lastGameAt:     make(map[uint]time.Time),
+		decks:          make(map[int]glickoRating),
+		deckGamesCount: make(map[int]int),
+		deckLastGameAt: make(map[int]time.Time),
+	}
+}
+
+func (e *ratingEngine) deckRating(deckID int) glickoRating {
+	if r, ok := e.decks[deckID]; ok {
+		return r
+	}
+	return newGlickoRating()
+}
+
+// loadDecks подгружает сохранённые рейтинги колод (для инкрементального обновления).
+func (e *ratingEngine) loadDecks(db *gorm.DB, deckIDs []int) error {
+	if len(deckIDs) == 0 {
+		return nil
+	}
+	var rows []models.DeckRating
+	if err := db.Where("deck_id IN ?", deckIDs).Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, r := range rows {
+		e.decks[r.DeckID] = glickoRating{Rating: r.Rating, Deviation: r.Deviation, Volatility: r.Volatility}
+		e.deckGamesCount[r.DeckID] = r.GamesCount
+		if r.LastGameAt != nil {
+			e.deckLastGameAt[r.DeckID] = *r.LastGameAt
+		}
+	}
+	return nil
+}
+
+func (e *ratingEngine) playerRating(userID uint) glickoRating {
+	if r, ok := e.players[userID]; ok {
+		return r
+	}
+	return newGlickoRating()
+}
+
+// loadPlayers подгружает сохранённые рейтинги игроков (для инкрементального обновления).
+func (e *ratingEngine) loadPlayers(db *gorm.DB, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	var rows []models.PlayerRating
+	if err := db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, r := range rows {
+		e.players[r.UserID] = glickoRating{Rating: r.Rating, Deviation: r.Deviation, Volatility: r.Volatility}
+		e.gamesCount[r.UserID] = r.GamesCount
+		if r.LastGameAt != nil {
+			e.lastGameAt[r.UserID] = *r.LastGameAt
+		}
+	}
+	return nil
+}
+
+// applyGame обновляет рейтинги участников завершённой игры и возвращает записи истории.
+func (e *ratingEngine) applyGame(g models.Game) []models.PlayerRatingHistory {
+	if g.EndTime == nil || g.WinningTeam == nil {
+		return nil
+	}
+	players := sortedGamePlayers(g.Players)
+	var teams [3][]uint
+	var seats [3][]models.GamePlayer
+	for i, p := range players {
+		t := playerTeam(i, len(players))
+		teams[t] = append(teams[t], p.UserID)
+		seats[t] = append(seats[t], p)
+	}
+	if len(teams[1]) == 0 || len(teams[2]) == 0 {
+		return nil
+	}
+
+	// Колоды считаются по рейтингам пилотов до игры, поэтому до обновления игроков.
+	for deckID, results := range e.deckResults(seats, *g.WinningTeam) {
+		e.decks[deckID] = e.deckRating(deckID).update(results)
+		e.deckGamesCount[deckID]++
+		e.deckLastGameAt[deckID] = *g.EndTime
+	}
+
+	var composites [3]glickoRating
+	for t := 1; t <= 2; t++ {
+		members := make([]glickoRating, 0, len(teams[t]))
+		for _, id := range teams[t] {
+			members = append(members, e.playerRating(id))
+		}
+		composites[t] = teamComposite(members)
+	}
+
+	history := make([]models.PlayerRatingHistory, 0, len(players))
+	updated := make(map[uint]glickoRating, len(players))
+	for t := 1; t <= 2; t++ {
+		opp := composites[3-t]
+		score := 0.0
+		if t == *g.WinningTeam {
+			score = 1
+		}
+		for _, id := range teams[t] {
+			before := e.playerRating(id)
+			after := before.update([]glickoResult{{Rating: opp.Rating, Deviation: opp.Deviation, Score: score}})
+			updated[id] = after
+			history = append(history, models.PlayerRatingHistory{
+				UserID:          id,
+				GameID:          g.ID,
+				PlayedAt:        *g.EndTime,
+				Won:             score == 1,
+				RatingBefore:    before.Rating,
+				RatingAfter:     after.Rating,
+				DeviationBefore: before.Deviation,
+				DeviationAfter:  after.Deviation,
+				Volatility:      after.Volatility,
+			})
+		}
+	}
+	// Рейтинги меняются одновременно, чтобы порядок игроков в партии не влиял на результат.
+	for id, r := range updated {
+		e.players[id] = r
+		e.gamesCount[id]++
+		e.lastGameAt[id] = *g.EndTime
+	}
+	return history
+}
+
+// deckResults — результаты колод в игре с поправкой на пилотов.
+// Сила места — рейтинг колоды плюс отклонение рейтинга пилота от начального; сила команды — среднее по местам.
+// Эффективный соперник колоды подбирается так, чтобы разница «колода − соперник» равнялась разнице сил команд:
+// сильный пилот и сильные союзники повышают планку, сильные противники — снижают.
+// Места без колоды (deck_id <= 0) участвуют в силе команды с начальным рейтингом, но сами не обновляются.
+func (e *ratingEngine) deckResults(seats [3][]models.GamePlayer, winningTeam int) map[int][]glickoResult {
+	var strength, deviation [3]float64
+	for t := 1; t <= 2; t++ {
+		var sum, sumSq float64
+		for _, p := range seats[t] {
+			deck := e.deckRating(p.DeckID)
+			sum += deck.Rating + e.playerRating(p.UserID).Rating - glickoDefaultRating
+			sumSq += deck.Deviation * deck.Deviation
+		}
+		n := float64(len(seats[t]))
+		strength[t] = sum / n
+		deviation[t] = math.Sqrt(sumSq / n)
+	}
+
+	results := make(map[int][]glickoResult)
+	for t := 1; t <= 2; t++ {
+		score := 0.0
+		if t == winningTeam {
+			score = 1
+		}
+		for _, p := range seats[t] {
+			if p.DeckID <= 0 {
+				continue
+			}
+			deck := e.deckRating(p.DeckID)
+			opponent := deck.Rating - (strength[t] - strength[3-t])
+			results[p.DeckID] = append(results[p.DeckID], glickoResult{
+				Rating:    opponent,
+				Deviation: deviation[3-t],
+				Score:     score,
+			})
+		}
+	}
+	return results
+}
+
+// deckRows — текущие рейтинги колод для сохранения; deckIDs == nil — все колоды.
+func (e *ratingEngine) deckRows(deckIDs []int, now time.Time) []models.DeckRating {
+	if deckIDs == nil {
+		for id := range e.decks {
+			deckIDs = append(deckIDs, id)
+		}
+	}
+	rows := make([]models.DeckRating, 0, len(deckIDs))
+	for _, id := range deckIDs {
+		r, ok := e.decks[id]
+		if !ok {
+			continue
+		}
+		row := models.DeckRating{
+			DeckID:     id,
+			Rating:     r.Rating,
+			Deviation:  r.Deviation,
+			Volatility: r.Volatility,
+			GamesCount: e.deckGamesCount[id],
+			UpdatedAt:  now,
+		}
+		if t, ok := e.deckLastGameAt[id]; ok {
+			last := t
+			row.LastGameAt = &last
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// playerRows — текущие рейтинги для сохранения; userIDs == nil — все игроки.
+func (e *ratingEngine) playerRows(userIDs []uint, now time.Time) []models.PlayerRating {
+	if userIDs == nil {
+		for id := range e.players {
+			userIDs = append(userIDs, id)
+		}
+	}
+	rows := make([]models.PlayerRating, 0, len(userIDs))
+	for _, id := range userIDs {
+		r, ok := e.players[id]
+		if !ok {
+			continue
+		}
+		row := models.PlayerRating{
+			UserID:     id,
+			Rating:     r.Rating,
+			Deviation:  r.Deviation,
+			Volatility: r.Volatility,
+			GamesCount: e.gamesCount[id],
+			UpdatedAt:  now,
+		}
+		if t, ok := e.lastGameAt[id]; ok {
+			last := t
+			row.LastGameAt = &last
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// applyGameRatings — инкрементальное обновление рейтингов после завершения игры (в транзакции FinishGame).
+// Корректно только для самой поздней игры; для игр задним числом нужен recomputeRatings.
+func applyGameRatings(tx *gorm.DB, gameID uint) error {
+	var game models.Game
+	if err := tx.Preload("Players").First(&game, gameID).Error; err != nil {
+		return err
+	}
+	userIDs := make([]uint, 0, len(game.Players))
+	deckIDs := make([]int, 0, len(game.Players))
+	seenDecks := make(map[int]bool, len(game.Players))
+	for _, p := range game.Players {
+		userIDs = append(userIDs, p.UserID)
+		if p.DeckID > 0 && !seenDecks[p.DeckID] {
+			seenDecks[p.DeckID] = true
+			deckIDs = append(deckIDs, p.DeckID)
+		}
+	}
+	engine := newRatingEngine()
+	if err := engine.loadPlayers(tx, userIDs); err != nil {
+		return err
+	}
+	if err := engine.loadDecks(tx, deckIDs); err != nil {
+		return err
+	}
+	history := engine.applyGame(game)
+	if len(history) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	rows := engine.playerRows(userIDs, now)
+	if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+		return err
+	}
+	if deckRows := engine.deckRows(deckIDs, now); len(deckRows) > 0 {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&deckRows).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(&history).Error
+}
+
+// recomputeRatings — полный пересчёт рейтингов по всем завершённым играм в хронологическом порядке.
+// Нужен после правок, импорта и записи игр задним числом. Возвращает число учтённых игр.
+func recomputeRatings(tx *gorm.DB) (int, error) {
+	var games []models.Game
+	if err := tx.Where("end_time IS NOT NULL AND winning_team IS NOT NULL").
+		Order("end_time ASC, id ASC").
+		Preload("Players").
+		Find(&games).Error; err != nil {
+		return 0, err
+	}
+
+	engine := newRatingEngine()
+	history := make([]models.PlayerRatingHistory, 0, len(games)*4)
+	for _, g := range games {
+		history = append(history, engine.applyGame(g)...)
+	}
+
+	if err := tx.Exec("DELETE FROM player_rating_history").Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Exec("DELETE FROM player_ratings").Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Exec("DELETE FROM deck_ratings").Error; err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	rows := engine.playerRows(nil, now)
+	if len(rows) > 0 {
+		if err := tx.CreateInBatches(&rows, 500).Error; err != nil {
+			return 0, err
+		}
+	}
+	deckRows := engine.deckRows(nil, now)
+	if len(deckRows) > 0 {
+		if err := tx.CreateInBatches(&deckRows, 500).Error; err != nil {
+			return 0, err
+		}
+	}
+	if len(history) > 0 {
+		if err := tx.CreateInBatches(&history, 500).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(games), nil
+}
+
+// GetPlayerRatings — текущие рейтинги Glicko-2 игроков по убыванию рейтинга.
+func GetPlayerRatings(c *gin.Context) {
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	type ratingRow struct {
+		models.PlayerRating
+		PlayerName string `gorm:"column:player_name"`
+	}
+	var rows []ratingRow
+	if err := db.Table("player_ratings AS pr").
+		Select("pr.*, u.name AS player_name").
+		Joins("JOIN users u ON u.id = pr.user_id").
+		Order("pr.rating DESC, u.name ASC").
+		Scan(&rows).Error; err != nil {
+		log.Printf("GetPlayerRatings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить рейтинги"})
+		return
+	}
+
+	_, loc, _ := resolveConfiguredTimezone()
+	out := make([]models.PlayerRatingStats, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, playerRatingStats(r.PlayerRating, r.PlayerName, loc))
+	}
+	writeStatsCacheJSON(c, out)
+}
+
+// loadDeckRatings — текущие рейтинги колод по deck_id (для статистики колод и мета-дашборда).
+func loadDeckRatings(db *gorm.DB) (map[int]models.DeckRating, error) {
+	var rows []models.DeckRating
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]models.DeckRating, len(rows))
+	for _, r := range rows {
+		out[r.DeckID] = r
+	}
+	return out, nil
+}
+
+func playerRatingStats(r models.PlayerRating, name string, loc *time.Location) models.PlayerRatingStats {
+	return models.PlayerRatingStats{
+		UserID:             r.UserID,
+		PlayerName:         name,
+		Rating:             r.Rating,
+		Deviation:          r.Deviation,
+		Volatility:         r.Volatility,
+		ConservativeRating: r.Rating - 2*r.Deviation,
+		GamesCount:         r.GamesCount,
+		LastGameAt:         inLocationPtr(r.LastGameAt, loc),
+	}
+}
+
+// GetPlayerRatingHistory — траектория рейтинга игрока по играм; 404 если пользователь не найден.
+func GetPlayerRatingHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+	var history []models.PlayerRatingHistory
+	if err := db.Where("user_id = ?", id).Order("played_at ASC, id ASC").Find(&history).Error; err != nil {
+		log.Printf("GetPlayerRatingHistory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить историю рейтинга"})
+		return
+	}
+
+	_, loc, _ := resolveConfiguredTimezone()
+	resp := models.PlayerRatingHistoryResponse{
+		UserID:     user.ID,
+		PlayerName: user.Name,
+		History:    make([]models.RatingHistoryPoint, 0, len(history)),
+	}
+	var current models.PlayerRating
+	if err := db.First(&current, "user_id = ?", id).Error; err == nil {
+		stat := playerRatingStats(current, user.Name, loc)
+		resp.Current = &stat
+	}
+	for _, h := range history {
+		resp.History = append(resp.History, models.RatingHistoryPoint{
+			GameID:          h.GameID,
+			PlayedAt:        inLocation(h.PlayedAt, loc),
+			Won:             h.Won,
+			RatingBefore:    h.RatingBefore,
+			RatingAfter:     h.RatingAfter,
+			RatingChange:    h.RatingAfter - h.RatingBefore,
+			DeviationBefore: h.DeviationBefore,
+			DeviationAfter:  h.DeviationAfter,
+		})
+	}
+	writeStatsCacheJSON(c, resp)
+}
+
+// RecomputeRatings — полный пересчёт рейтингов по всем завершённым играм (только админ).
+func RecomputeRatings(c *gin.Context) {
+	db := database.GetDB()
+	tx := db.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось начать транзакцию пересчёта"})
+		return
+	}
+	games, err := recomputeRatings(tx)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("RecomputeRatings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересчитать рейтинги"})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось завершить транзакцию пересчёта"})
+		return
+	}
+	invalidateStatsCache()
+	c.JSON(http.StatusOK, gin.H{"message": "Рейтинги пересчитаны", "games": games})
+}
@@ -21,6 +21,7 @@ func userToResponse(u models.User, viewer *middleware.UserInfo, loc *time.Locati
 }
 
 // gameToResponse конвертирует Game в GameResponse с маскировкой is_admin в players.
+// Общее время пауз и сдвиг начала хода считаются по интервалам game_pauses.
 func gameToResponse(g models.Game, viewer *middleware.UserInfo, loc *time.Location) models.GameResponse {
 	players := make([]models.GamePlayerResponse, len(g.Players))
 	for i := range g.Players {
@@ -31,6 +32,18 @@ func gameToResponse(g models.Game, viewer *middleware.UserInfo, loc *time.Locati
 			DeckName: g.Players[i].DeckName,
 		}
 	}
+	pauses := make([]models.GamePause, len(g.Pauses))
+	for i, p := range g.Pauses {
+		p.StartedAt = inLocation(p.StartedAt, loc)
+		p.EndedAt = inLocationPtr(p.EndedAt, loc)
+		pauses[i] = p
+	}
+	turns := make([]models.GameTurn, len(g.Turns))
+	for i, t := range g.Turns {
+		t.StartedAt = inLocationPtr(t.StartedAt, loc)
+		t.EndedAt = inLocationPtr(t.EndedAt, loc)
+		turns[i] = t
+	}
 	return models.GameResponse{
 		ID:                        g.ID,
 		PublicViewToken:           g.ViewToken,
@@ -41,15 +54,20 @@ func gameToResponse(g models.Game, viewer *middleware.UserInfo, loc *time.Locati
 		Team1Name:                 g.Team1Name,
 		Team2Name:                 g.Team2Name,
 		Players:                   players,
-		Turns:                     g.Turns,
+		Turns:                     turns,
+		Pauses:                    pauses,
 		CurrentTurnTeam:           g.CurrentTurnTeam,
-		CurrentTurnStart:          inLocationPtr(g.CurrentTurnStart, loc),
+		CurrentTurnStart:          inLocationPtr(pauseAdjustedTurnStart(g.CurrentTurnStart, g.Pauses), loc),
 		IsPaused:                  g.IsPaused,
 		PauseStartedAt:            inLocationPtr(g.PauseStartedAt, loc),
-		TotalPauseDurationSeconds: g.TotalPauseDurationSeconds,
+		TotalPauseDurationSeconds: gamePauseSeconds(g),
 		TeamTimeLimitSeconds:      g.TeamTimeLimitSeconds,
 		IsTechnicalDefeat:         g.IsTechnicalDefeat,
 		WinningTeam:               g.WinningTeam,
+		MatchID:                   g.MatchID,
+		Version:                   g.Version,
+		ETag:                      gameETag(g),
+		ServerNow:                 inLocation(time.Now(), loc),
 		CreatedAt:                 inLocation(g.CreatedAt, loc),
 		UpdatedAt:                 inLocation(g.UpdatedAt, loc),
 	}
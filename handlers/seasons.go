@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSeasonPointsWin  = 3
+	defaultSeasonPointsLoss = 0
+)
+
+// seasonDateRange — границы сезона в часовом поясе loc: начало start_date и конец end_date (UTC).
+func seasonDateRange(s models.Season, loc *time.Location) (time.Time, time.Time, error) {
+	from, err := parseStatsDay(s.StartDate, loc, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseStatsDay(s.EndDate, loc, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+// applySeasonRequest проверяет даты и очки запроса и переносит их в сезон.
+func applySeasonRequest(s *models.Season, req models.SeasonRequest) error {
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return fmt.Errorf("Некорректная start_date, формат YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return fmt.Errorf("Некорректная end_date, формат YYYY-MM-DD")
+	}
+	if start.After(end) {
+		return fmt.Errorf("start_date должна быть <= end_date")
+	}
+	s.Name = req.Name
+	s.StartDate = req.StartDate
+	s.EndDate = req.EndDate
+	s.PointsWin = defaultSeasonPointsWin
+	if req.PointsWin != nil {
+		s.PointsWin = *req.PointsWin
+	}
+	s.PointsLoss = defaultSeasonPointsLoss
+	if req.PointsLoss != nil {
+		s.PointsLoss = *req.PointsLoss
+	}
+	return nil
+}
+
+// computeSeasonStandings — турнирная таблица сезона по текущим завершённым играм.
+// Порядок: очки, победы, процент побед, имя; одинаковые очки и победы делят место.
+func computeSeasonStandings(db *gorm.DB, season models.Season) ([]models.SeasonStanding, error) {
+	_, loc, _ := resolveConfiguredTimezone()
+	from, to, err := seasonDateRange(season, loc)
+	if err != nil {
+		return nil, err
+	}
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", statsFilter{From: &from, To: &to, IncludeTechnical: true})
+
+	type standingRow struct {
+		UserID     uint   `gorm:"column:user_id"`
+		PlayerName string `gorm:"column:player_name"`
+		GamesCount int    `gorm:"column:games_count"`
+		WinsCount  int    `gorm:"column:wins_count"`
+	}
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT
+			user_id,
+			MAX(player_name) AS player_name,
+			COUNT(*) AS games_count,
+			SUM(CASE WHEN player_team = winning_team THEN 1 ELSE 0 END) AS wins_count
+		FROM players_with_team
+		GROUP BY user_id
+	`, playersWithTeamCTE(whereClause))
+	var rows []standingRow
+	if err := db.Raw(query, whereArgs...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	standings := make([]models.SeasonStanding, 0, len(rows))
+	for _, r := range rows {
+		losses := r.GamesCount - r.WinsCount
+		winRate := 0.0
+		if r.GamesCount > 0 {
+			winRate = float64(r.WinsCount) / float64(r.GamesCount) * 100
+		}
+		standings = append(standings, models.SeasonStanding{
+			SeasonID:    season.ID,
+			UserID:      r.UserID,
+			PlayerName:  r.PlayerName,
+			GamesCount:  r.GamesCount,
+			WinsCount:   r.WinsCount,
+			LossesCount: losses,
+			WinRate:     winRate,
+			Points:      r.WinsCount*season.PointsWin + losses*season.PointsLoss,
+		})
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.WinsCount != b.WinsCount {
+			return a.WinsCount > b.WinsCount
+		}
+		if a.WinRate != b.WinRate {
+			return a.WinRate > b.WinRate
+		}
+		return a.PlayerName < b.PlayerName
+	})
+	for i := range standings {
+		if i > 0 && standings[i].Points == standings[i-1].Points && standings[i].WinsCount == standings[i-1].WinsCount {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings, nil
+}
+
+// loadSeason — сезон по :id; при ошибке пишет 400/404 и возвращает ok == false.
+func loadSeason(c *gin.Context, db *gorm.DB) (models.Season, bool) {
+	var season models.Season
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID сезона"})
+		return season, false
+	}
+	if err := db.First(&season, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Сезон не найден"})
+		return season, false
+	}
+	return season, true
+}
+
+// GetSeasons — список сезонов, новые сначала.
+func GetSeasons(c *gin.Context) {
+	db := database.GetDB()
+	var seasons []models.Season
+	if err := db.Order("start_date DESC, id DESC").Find(&seasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список сезонов"})
+		return
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	for i := range seasons {
+		seasons[i] = seasonInLocation(seasons[i], loc)
+	}
+	c.JSON(http.StatusOK, seasons)
+}
+
+// GetSeason — сезон по id; 404 если не найден.
+func GetSeason(c *gin.Context) {
+	season, ok := loadSeason(c, database.GetDB())
+	if !ok {
+		return
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	c.JSON(http.StatusOK, seasonInLocation(season, loc))
+}
+
+// GetSeasonStandings — турнирная таблица: у закрытого сезона — замороженный снимок, у открытого — расчёт по играм.
+func GetSeasonStandings(c *gin.Context) {
+	db := database.GetDB()
+	season, ok := loadSeason(c, db)
+	if !ok {
+		return
+	}
+	resp := models.SeasonStandingsResponse{Frozen: season.ClosedAt != nil}
+	if resp.Frozen {
+		if err := db.Where("season_id = ?", season.ID).Order("rank ASC, player_name ASC").Find(&resp.Standings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить таблицу сезона"})
+			return
+		}
+	} else {
+		standings, err := computeSeasonStandings(db, season)
+		if err != nil {
+			log.Printf("GetSeasonStandings: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать таблицу сезона"})
+			return
+		}
+		resp.Standings = standings
+	}
+	if resp.Standings == nil {
+		resp.Standings = []models.SeasonStanding{}
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	resp.Season = seasonInLocation(season, loc)
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateSeason — создание сезона; только администратор.
+func CreateSeason(c *gin.Context) {
+	var req models.SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные", "details": err.Error()})
+		return
+	}
+	var season models.Season
+	if err := applySeasonRequest(&season, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := database.GetDB()
+	if err := db.Create(&season).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать сезон"})
+		return
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	c.JSON(http.StatusCreated, seasonInLocation(season, loc))
+}
+
+// UpdateSeason — изменение названия, дат и очков; закрытый сезон не меняется (409).
+func UpdateSeason(c *gin.Context) {
+	var req models.SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные", "details": err.Error()})
+		return
+	}
+	db := database.GetDB()
+	season, ok := loadSeason(c, db)
+	if !ok {
+		return
+	}
+	if season.ClosedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Сезон закрыт, изменения запрещены"})
+		return
+	}
+	if err := applySeasonRequest(&season, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Save(&season).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить сезон"})
+		return
+	}
+	invalidateStatsCache()
+	_, loc, _ := resolveConfiguredTimezone()
+	c.JSON(http.StatusOK, seasonInLocation(season, loc))
+}
+
+// DeleteSeason — удаление сезона вместе со снимком таблицы.
+func DeleteSeason(c *gin.Context) {
+	db := database.GetDB()
+	season, ok := loadSeason(c, db)
+	if !ok {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("season_id = ?", season.ID).Delete(&models.SeasonStanding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Season{}, season.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось удалить сезон"})
+		return
+	}
+	invalidateStatsCache()
+	c.JSON(http.StatusOK, gin.H{"message": "Сезон удалён"})
+}
+
+// CloseSeason — закрытие сезона: таблица замораживается снимком, последующие правки игр её не меняют.
+func CloseSeason(c *gin.Context) {
+	db := database.GetDB()
+	season, ok := loadSeason(c, db)
+	if !ok {
+		return
+	}
+	if season.ClosedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Сезон уже закрыт"})
+		return
+	}
+	standings, err := computeSeasonStandings(db, season)
+	if err != nil {
+		log.Printf("CloseSeason: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать таблицу сезона"})
+		return
+	}
+	now := time.Now().UTC()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("season_id = ?", season.ID).Delete(&models.SeasonStanding{}).Error; err != nil {
+			return err
+		}
+		if len(standings) > 0 {
+			if err := tx.Create(&standings).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&season).Update("closed_at", now).Error
+	})
+	if err != nil {
+		log.Printf("CloseSeason: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось закрыть сезон"})
+		return
+	}
+	season.ClosedAt = &now
+	_, loc, _ := resolveConfiguredTimezone()
+	c.JSON(http.StatusOK, models.SeasonStandingsResponse{
+		Season:    seasonInLocation(season, loc),
+		Frozen:    true,
+		Standings: standings,
+	})
+}
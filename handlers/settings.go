@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"mtg-stats-backend/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -62,18 +64,31 @@ func UpdateSettings(c *gin.Context) {
 		return
 	}
 
+	previousTimezone, _, _ := resolveConfiguredTimezone()
 	db := database.GetDB()
 	setting := models.AppSetting{
 		Key:   models.AppSettingTimezoneKey,
 		Value: req.Timezone,
 	}
-	if err := db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "key"}},
-		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
-	}).Create(&setting).Error; err != nil {
+	// Смена часового пояса сдвигает границы дней и недель: периоды мета-дашборда пересобираются в той же транзакции.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&setting).Error; err != nil {
+			return err
+		}
+		if req.Timezone == previousTimezone || !statsAggregatesReady(tx) {
+			return nil
+		}
+		return rebuildPeriodAggregates(tx, loc)
+	})
+	if err != nil {
+		log.Printf("UpdateSettings: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить настройки"})
 		return
 	}
+	invalidateStatsCache()
 
 	_, offsetSeconds := time.Now().In(loc).Zone()
 	c.JSON(http.StatusOK, SettingsResponse{
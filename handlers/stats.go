@@ -23,32 +23,23 @@ type playerStreaks struct {
 	MaxLossStreak     *int
 }
 
-func computePlayerStreaks(db *gorm.DB) map[uint]playerStreaks {
-	const streakQuery = `
-		WITH ranked_players AS (
-			SELECT
-				gp.user_id,
-				g.end_time,
-				g.winning_team,
-				ROW_NUMBER() OVER (PARTITION BY gp.game_id ORDER BY gp.id) AS player_index,
-				COUNT(*) OVER (PARTITION BY gp.game_id) AS players_count
-			FROM game_players gp
-			JOIN games g ON g.id = gp.game_id
-			WHERE g.end_time IS NOT NULL AND g.winning_team IS NOT NULL
-		)
+// computePlayerStreaks — серии побед и поражений игроков по играм, отобранным условием where (см. statsFilter).
+func computePlayerStreaks(db *gorm.DB, where string, whereArgs []interface{}) map[uint]playerStreaks {
+	streakQuery := fmt.Sprintf(`
+		WITH %s
 		SELECT
 			user_id,
 			end_time,
-			(CASE WHEN player_index <= (players_count / 2) THEN 1 ELSE 2 END) = winning_team AS won
-		FROM ranked_players
+			player_team = winning_team AS won
+		FROM players_with_team
 		ORDER BY user_id, end_time
-	`
+	`, playersWithTeamCTE(where))
 	var rawRows []struct {
 		UserID  uint      `gorm:"column:user_id"`
 		EndTime time.Time `gorm:"column:end_time"`
 		Won     bool      `gorm:"column:won"`
 	}
-	if err := db.Raw(streakQuery).Scan(&rawRows).Error; err != nil {
+	if err := db.Raw(streakQuery, whereArgs...).Scan(&rawRows).Error; err != nil {
 		return nil
 	}
 	byUser := make(map[uint][]bool)
@@ -76,20 +67,38 @@ func computePlayerStreaks(db *gorm.DB) map[uint]playerStreaks {
 				}
 			}
 		}
-		s := playerStreaks{}
-		if maxWin > 0 {
-			s.MaxWinStreak = &maxWin
-		}
-		if maxLoss > 0 {
-			s.MaxLossStreak = &maxLoss
-		}
-		if curWin > 0 {
-			s.CurrentWinStreak = &curWin
-		}
-		if curLoss > 0 {
-			s.CurrentLossStreak = &curLoss
-		}
-		result[userID] = s
+		result[userID] = newPlayerStreaks(curWin, curLoss, maxWin, maxLoss)
+	}
+	return result
+}
+
+// newPlayerStreaks — серии для ответа: нулевые не выводятся.
+func newPlayerStreaks(curWin, curLoss, maxWin, maxLoss int) playerStreaks {
+	s := playerStreaks{}
+	if maxWin > 0 {
+		s.MaxWinStreak = &maxWin
+	}
+	if maxLoss > 0 {
+		s.MaxLossStreak = &maxLoss
+	}
+	if curWin > 0 {
+		s.CurrentWinStreak = &curWin
+	}
+	if curLoss > 0 {
+		s.CurrentLossStreak = &curLoss
+	}
+	return s
+}
+
+// aggregatedPlayerStreaks — серии игроков из stats_players.
+func aggregatedPlayerStreaks(db *gorm.DB) map[uint]playerStreaks {
+	var rows []models.StatsPlayerAggregate
+	if err := db.Find(&rows).Error; err != nil {
+		return nil
+	}
+	result := make(map[uint]playerStreaks, len(rows))
+	for _, r := range rows {
+		result[r.UserID] = newPlayerStreaks(r.CurrentWinStreak, r.CurrentLossStreak, r.MaxWinStreak, r.MaxLossStreak)
 	}
 	return result
 }
@@ -102,9 +111,18 @@ func teamForPlayerIndex(i int) int {
 	return 2
 }
 
-// GetPlayerStats — агрегат по игрокам по завершённым играм (победы, ходы, лучшая колода).
-// Считается SQL-агрегацией без загрузки всех игр в память.
+// GetPlayerStats — агрегат по игрокам по завершённым играм (победы, ходы, лучшая колода) и по решённым матчам.
+// Без фильтров читается из агрегатов stats_players/stats_player_decks, с фильтрами — SQL-агрегацией по играм.
+// Поддерживает общие фильтры (statsFilter) и sort (parseWinRateSort); по умолчанию — по имени игрока.
 func GetPlayerStats(c *gin.Context) {
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	order, ok := parseWinRateSort(c)
+	if !ok {
+		return
+	}
 	if writeStatsCacheHit(c) {
 		return
 	}
@@ -124,30 +142,38 @@ func GetPlayerStats(c *gin.Context) {
 		BestDeckGames      int    `gorm:"column:best_deck_games"`
 	}
 
-	const query = `
-		WITH ranked_players AS (
-			SELECT
-				gp.game_id,
-				gp.user_id,
-				gp.deck_id,
-				gp.deck_name,
-				u.name AS player_name,
-				g.winning_team,
-				g.first_move_team,
-				ROW_NUMBER() OVER (PARTITION BY gp.game_id ORDER BY gp.id) AS player_index,
-				COUNT(*) OVER (PARTITION BY gp.game_id) AS players_count
-			FROM game_players gp
-			JOIN games g ON g.id = gp.game_id
-			JOIN users u ON u.id = gp.user_id
-			WHERE g.end_time IS NOT NULL
-				AND g.winning_team IS NOT NULL
-		),
-		players_with_team AS (
-			SELECT
-				*,
-				CASE WHEN player_index <= (players_count / 2) THEN 1 ELSE 2 END AS player_team
-			FROM ranked_players
-		),
+	const aggregatedQuery = `
+		WITH best_deck AS (
+			SELECT DISTINCT ON (user_id)
+				user_id,
+				deck_name AS best_deck_name,
+				wins_count AS best_deck_wins,
+				games_count AS best_deck_games
+			FROM stats_player_decks
+			ORDER BY user_id, (wins_count::float / NULLIF(games_count, 0)) DESC NULLS LAST, wins_count DESC, games_count DESC, deck_name ASC
+		)
+		SELECT
+			sp.user_id,
+			u.name AS player_name,
+			sp.games_count,
+			sp.wins_count,
+			sp.first_move_wins,
+			sp.first_move_games,
+			CASE WHEN sp.turns_count > 0 THEN ROUND(sp.turns_duration_sec::numeric / sp.turns_count)::int ELSE 0 END AS avg_turn_duration_sec,
+			sp.max_turn_duration_sec,
+			COALESCE(bd.best_deck_name, '') AS best_deck_name,
+			COALESCE(bd.best_deck_wins, 0) AS best_deck_wins,
+			COALESCE(bd.best_deck_games, 0) AS best_deck_games
+		FROM stats_players sp
+		JOIN users u ON u.id = sp.user_id
+		LEFT JOIN best_deck bd ON bd.user_id = sp.user_id
+		WHERE sp.games_count >= ?
+		ORDER BY u.name ASC
+	`
+
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	query := fmt.Sprintf(`
+		WITH %s,
 		player_games AS (
 			SELECT
 				user_id,
@@ -208,16 +234,33 @@ func GetPlayerStats(c *gin.Context) {
 		FROM player_games pg
 		LEFT JOIN player_turns pt ON pt.user_id = pg.user_id
 		LEFT JOIN best_deck bd ON bd.user_id = pg.user_id
+		WHERE pg.games_count >= ?
 		ORDER BY pg.player_name ASC
-	`
+	`, playersWithTeamCTE(whereClause))
+	args := append(append([]interface{}{}, whereArgs...), filter.minGamesOr(1))
+	aggregated := useStatsAggregates(db, filter)
+	if aggregated {
+		query, args = aggregatedQuery, []interface{}{filter.minGamesOr(1)}
+	}
 	var rows []playerStatsRow
-	if err := db.Raw(query).Scan(&rows).Error; err != nil {
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
 		log.Printf("GetPlayerStats: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику игроков"})
 		return
 	}
 
-	streaks := computePlayerStreaks(db)
+	var streaks map[uint]playerStreaks
+	if aggregated {
+		streaks = aggregatedPlayerStreaks(db)
+	} else {
+		streaks = computePlayerStreaks(db, whereClause, whereArgs)
+	}
+	matchRecords, err := loadMatchRecords(db, "user_id", whereClause, whereArgs)
+	if err != nil {
+		log.Printf("GetPlayerStats: matches: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику матчей"})
+		return
+	}
 
 	out := make([]models.PlayerStats, 0, len(rows))
 	for _, r := range rows {
@@ -243,6 +286,7 @@ func GetPlayerStats(c *gin.Context) {
 			BestDeckName:        r.BestDeckName,
 			BestDeckWins:        r.BestDeckWins,
 			BestDeckGames:       r.BestDeckGames,
+			WinRateConfidence:   winRateConfidence(r.WinsCount, r.GamesCount),
 		}
 		if s, ok := streaks[r.UserID]; ok {
 			stat.CurrentWinStreak = s.CurrentWinStreak
@@ -250,14 +294,26 @@ func GetPlayerStats(c *gin.Context) {
 			stat.MaxWinStreak = s.MaxWinStreak
 			stat.MaxLossStreak = s.MaxLossStreak
 		}
+		if m, ok := matchRecords[int(r.UserID)]; ok {
+			stat.MatchesCount, stat.MatchWins, stat.MatchWinPercent = m.MatchesCount, m.MatchWins, m.winPercent()
+		}
 		out = append(out, stat)
 	}
+	sortByWinRate(order, out, func(i int) (int, int) { return out[i].WinsCount, out[i].GamesCount })
 	writeStatsCacheJSON(c, out)
 }
 
-// GetDeckStats — агрегат по колодам по завершённым играм (игры, победы, %).
-// Загружает все завершённые игры в память; при большом объёме данных рассмотреть SQL-агрегацию.
+// GetDeckStats — агрегат по колодам по завершённым играм (игры, победы, %) и по решённым матчам; без фильтров — из stats_decks.
+// Поддерживает общие фильтры (statsFilter) и sort (parseWinRateSort).
 func GetDeckStats(c *gin.Context) {
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	order, ok := parseWinRateSort(c)
+	if !ok {
+		return
+	}
 	if writeStatsCacheHit(c) {
 		return
 	}
@@ -270,57 +326,72 @@ func GetDeckStats(c *gin.Context) {
 		WinsCount  int    `gorm:"column:wins_count"`
 	}
 	var rows []deckStatsRow
-	query := `
-		WITH ranked_players AS (
-			SELECT
-				gp.deck_id,
-				gp.deck_name,
-				g.winning_team,
-				ROW_NUMBER() OVER (PARTITION BY gp.game_id ORDER BY gp.id) AS player_index,
-				COUNT(*) OVER (PARTITION BY gp.game_id) AS players_count
-			FROM game_players gp
-			JOIN games g ON g.id = gp.game_id
-			WHERE g.end_time IS NOT NULL
-				AND g.winning_team IS NOT NULL
-		)
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	query := fmt.Sprintf(`
+		WITH %s
 		SELECT
 			deck_id,
 			MAX(deck_name) AS deck_name,
 			COUNT(*) AS games_count,
-			SUM(
-				CASE
-					WHEN (CASE WHEN player_index <= (players_count / 2) THEN 1 ELSE 2 END) = winning_team
-					THEN 1 ELSE 0
-				END
-			) AS wins_count
-		FROM ranked_players
+			SUM(CASE WHEN player_team = winning_team THEN 1 ELSE 0 END) AS wins_count
+		FROM players_with_team
 		GROUP BY deck_id
-	`
-	if err := db.Raw(query).Scan(&rows).Error; err != nil {
+		HAVING COUNT(*) >= ?
+	`, playersWithTeamCTE(whereClause))
+	args := append(append([]interface{}{}, whereArgs...), filter.minGamesOr(1))
+	if useStatsAggregates(db, filter) {
+		query = `SELECT deck_id, deck_name, games_count, wins_count FROM stats_decks WHERE games_count >= ?`
+		args = []interface{}{filter.minGamesOr(1)}
+	}
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
 		log.Printf("GetDeckStats: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику колод"})
 		return
 	}
 
+	ratings, err := loadDeckRatings(db)
+	if err != nil {
+		log.Printf("GetDeckStats: ratings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить рейтинги колод"})
+		return
+	}
+	matchRecords, err := loadMatchRecords(db, "deck_id", whereClause, whereArgs)
+	if err != nil {
+		log.Printf("GetDeckStats: matches: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику матчей"})
+		return
+	}
+
 	out := make([]models.DeckStats, 0, len(rows))
 	for _, r := range rows {
 		pct := 0.0
 		if r.GamesCount > 0 {
 			pct = float64(r.WinsCount) / float64(r.GamesCount) * 100
 		}
-		out = append(out, models.DeckStats{
-			DeckID:     r.DeckID,
-			DeckName:   r.DeckName,
-			GamesCount: r.GamesCount,
-			WinsCount:  r.WinsCount,
-			WinPercent: pct,
-		})
+		stat := models.DeckStats{
+			DeckID:            r.DeckID,
+			DeckName:          r.DeckName,
+			GamesCount:        r.GamesCount,
+			WinsCount:         r.WinsCount,
+			WinPercent:        pct,
+			WinRateConfidence: winRateConfidence(r.WinsCount, r.GamesCount),
+		}
+		if rating, ok := ratings[r.DeckID]; ok {
+			stat.Rating = &rating.Rating
+			stat.RatingDeviation = &rating.Deviation
+		}
+		if m, ok := matchRecords[r.DeckID]; ok {
+			stat.MatchesCount, stat.MatchWins, stat.MatchWinPercent = m.MatchesCount, m.MatchWins, m.winPercent()
+		}
+		out = append(out, stat)
 	}
+	sortByWinRate(order, out, func(i int) (int, int) { return out[i].WinsCount, out[i].GamesCount })
 	writeStatsCacheJSON(c, out)
 }
 
-func periodKey(t time.Time, groupBy string) string {
-	u := t.UTC()
+// periodKey — ключ периода мета-дашборда (как periodKeySQLExpr) для момента t в часовом поясе loc.
+func periodKey(t time.Time, groupBy string, loc *time.Location) string {
+	u := t.In(loc)
 	switch groupBy {
 	case "day":
 		return u.Format("2006-01-02")
@@ -328,46 +399,31 @@ func periodKey(t time.Time, groupBy string) string {
 		return u.Format("2006-01")
 	default:
 		year, week := u.ISOWeek()
-		return strings.Join([]string{strconv.Itoa(year), "W", strconv.Itoa(week)}, "")
+		return fmt.Sprintf("%dW%02d", year, week)
 	}
 }
 
-// GetDeckMatchups — матрица матчапов колод по завершённым играм.
-func GetDeckMatchups(c *gin.Context) {
-	if writeStatsCacheHit(c) {
-		return
-	}
+// deckMatchupRow — пара колод матрицы матчапов (deck1_id <= deck2_id); зеркало — deck1_id == deck2_id.
+type deckMatchupRow struct {
+	Deck1ID    int    `gorm:"column:deck1_id"`
+	Deck1Name  string `gorm:"column:deck1_name"`
+	Deck2ID    int    `gorm:"column:deck2_id"`
+	Deck2Name  string `gorm:"column:deck2_name"`
+	GamesCount int    `gorm:"column:games_count"`
+	Deck1Wins  int    `gorm:"column:deck1_wins"`
+	Deck2Wins  int    `gorm:"column:deck2_wins"`
+}
 
-	db := database.GetDB()
-	type deckMatchupRow struct {
-		Deck1ID    int    `gorm:"column:deck1_id"`
-		Deck1Name  string `gorm:"column:deck1_name"`
-		Deck2ID    int    `gorm:"column:deck2_id"`
-		Deck2Name  string `gorm:"column:deck2_name"`
-		GamesCount int    `gorm:"column:games_count"`
-		Deck1Wins  int    `gorm:"column:deck1_wins"`
-		Deck2Wins  int    `gorm:"column:deck2_wins"`
+// loadDeckMatchups — пары колод с разных команд партии от minGames партий; без фильтров — из stats_deck_matchups.
+// deckID > 0 — только пары с этой колодой (включая зеркала).
+func loadDeckMatchups(db *gorm.DB, filter statsFilter, minGames, deckID int) ([]deckMatchupRow, error) {
+	deckCond := ""
+	if deckID > 0 {
+		deckCond = " AND (deck1_id = ? OR deck2_id = ?)"
 	}
-	const query = `
-		WITH ranked_players AS (
-			SELECT
-				gp.game_id,
-				gp.deck_id,
-				gp.deck_name,
-				g.winning_team,
-				ROW_NUMBER() OVER (PARTITION BY gp.game_id ORDER BY gp.id) AS player_index,
-				COUNT(*) OVER (PARTITION BY gp.game_id) AS players_count
-			FROM game_players gp
-			JOIN games g ON g.id = gp.game_id
-			WHERE g.end_time IS NOT NULL
-				AND g.winning_team IS NOT NULL
-		),
-		players_with_team AS (
-			SELECT
-				*,
-				CASE WHEN player_index <= (players_count / 2) THEN 1 ELSE 2 END AS team_number
-			FROM ranked_players
-		),
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	query := fmt.Sprintf(`
+		WITH %s,
 		cross_pairs AS (
 			SELECT
 				p1.deck_id AS raw_deck1_id,
@@ -378,8 +434,8 @@ func GetDeckMatchups(c *gin.Context) {
 			FROM players_with_team p1
 			JOIN players_with_team p2
 				ON p1.game_id = p2.game_id
-				AND p1.team_number = 1
-				AND p2.team_number = 2
+				AND p1.player_team = 1
+				AND p2.player_team = 2
 		),
 		normalized AS (
 			SELECT
@@ -404,9 +460,42 @@ func GetDeckMatchups(c *gin.Context) {
 			COUNT(*) - SUM(deck1_win) AS deck2_wins
 		FROM normalized
 		GROUP BY deck1_id, deck2_id
-	`
+		HAVING COUNT(*) >= ?%s
+	`, playersWithTeamCTE(whereClause), deckCond)
+	args := append(append([]interface{}{}, whereArgs...), minGames)
+	if useStatsAggregates(db, filter) {
+		query = `
+			SELECT deck1_id, deck1_name, deck2_id, deck2_name, games_count, deck1_wins, games_count - deck1_wins AS deck2_wins
+			FROM stats_deck_matchups
+			WHERE games_count >= ?` + deckCond
+		args = []interface{}{minGames}
+	}
+	if deckID > 0 {
+		args = append(args, deckID, deckID)
+	}
 	var rows []deckMatchupRow
-	if err := db.Raw(query).Scan(&rows).Error; err != nil {
+	err := db.Raw(query, args...).Scan(&rows).Error
+	return rows, err
+}
+
+// GetDeckMatchups — матрица матчапов колод по завершённым играм; без фильтров — из stats_deck_matchups.
+// Поддерживает общие фильтры (statsFilter)
+// и sort (parseWinRateSort) — по более сильной стороне матчапа; по умолчанию — по названиям колод.
+func GetDeckMatchups(c *gin.Context) {
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	order, ok := parseWinRateSort(c)
+	if !ok {
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	rows, err := loadDeckMatchups(database.GetDB(), filter, filter.minGamesOr(1), 0)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить матрицу матчапов"})
 		return
 	}
@@ -419,16 +508,24 @@ func GetDeckMatchups(c *gin.Context) {
 			deck1Rate = float64(a.Deck1Wins) / float64(a.GamesCount) * 100
 			deck2Rate = float64(a.Deck2Wins) / float64(a.GamesCount) * 100
 		}
+		deck1 := winRateConfidence(a.Deck1Wins, a.GamesCount)
+		deck2 := winRateConfidence(a.Deck2Wins, a.GamesCount)
 		out = append(out, models.DeckMatchupStats{
-			Deck1ID:      a.Deck1ID,
-			Deck1Name:    a.Deck1Name,
-			Deck2ID:      a.Deck2ID,
-			Deck2Name:    a.Deck2Name,
-			GamesCount:   a.GamesCount,
-			Deck1Wins:    a.Deck1Wins,
-			Deck2Wins:    a.Deck2Wins,
-			Deck1WinRate: deck1Rate,
-			Deck2WinRate: deck2Rate,
+			Deck1ID:              a.Deck1ID,
+			Deck1Name:            a.Deck1Name,
+			Deck2ID:              a.Deck2ID,
+			Deck2Name:            a.Deck2Name,
+			GamesCount:           a.GamesCount,
+			Deck1Wins:            a.Deck1Wins,
+			Deck2Wins:            a.Deck2Wins,
+			Deck1WinRate:         deck1Rate,
+			Deck2WinRate:         deck2Rate,
+			Deck1WinRateLow:      deck1.WinRateLow,
+			Deck1WinRateHigh:     deck1.WinRateHigh,
+			Deck1BayesianWinRate: deck1.BayesianWinRate,
+			Deck2WinRateLow:      deck2.WinRateLow,
+			Deck2WinRateHigh:     deck2.WinRateHigh,
+			Deck2BayesianWinRate: deck2.BayesianWinRate,
 		})
 	}
 	sort.Slice(out, func(i, j int) bool {
@@ -437,10 +534,44 @@ func GetDeckMatchups(c *gin.Context) {
 		}
 		return out[i].Deck2Name < out[j].Deck2Name
 	})
+	sortByWinRate(order, out, func(i int) (int, int) {
+		if out[i].Deck2Wins > out[i].Deck1Wins {
+			return out[i].Deck2Wins, out[i].GamesCount
+		}
+		return out[i].Deck1Wins, out[i].GamesCount
+	})
 	writeStatsCacheJSON(c, models.DeckMatchupsResponse{Matchups: out})
 }
 
-// GetMetaDashboard — мета-срез по времени с агрегатами колод.
+const (
+	// metaMaxSmoothWindow — наибольшее окно скользящего среднего мета-дашборда (smooth).
+	metaMaxSmoothWindow = 12
+	// metaTrendDecks — колод в списках растущих и падающих.
+	metaTrendDecks = 5
+)
+
+// metaShare — доля игр колоды среди total игр периода, в процентах; 0, если колоды в периоде не было.
+func metaShare(a *metaDeckAgg, total int) float64 {
+	if a == nil || total == 0 {
+		return 0
+	}
+	return float64(a.games) / float64(total) * 100
+}
+
+// metaDeckAgg — игры и победы колоды за период или весь срез мета-дашборда.
+type metaDeckAgg struct {
+	id    int
+	name  string
+	games int
+	wins  int
+}
+
+// GetMetaDashboard — мета-срез по времени с агрегатами колод. Поддерживает общие фильтры (statsFilter):
+// min_games — порог игр для топов (для топа по проценту побед по умолчанию metaTopWinRateMinGames);
+// sort (parseWinRateSort) — порядок топа по проценту побед, по умолчанию win_rate.
+// Колоды периода несут изменение доли меты и процента побед к предыдущему периоду с играми;
+// top_rising_decks/top_falling_decks — наибольший рост и падение доли меты между двумя последними периодами
+// (от min_games игр в них); smooth=N — скользящее среднее за N последних периодов для линий тренда.
 func GetMetaDashboard(c *gin.Context) {
 	if writeStatsCacheHit(c) {
 		return
@@ -452,41 +583,34 @@ func GetMetaDashboard(c *gin.Context) {
 		return
 	}
 
-	var fromDate *time.Time
-	var toDate *time.Time
-	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
-		t, err := time.Parse("2006-01-02", raw)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный from, формат YYYY-MM-DD"})
-			return
-		}
-		u := t.UTC()
-		fromDate = &u
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
 	}
-	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
-		t, err := time.Parse("2006-01-02", raw)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный to, формат YYYY-MM-DD"})
+	fromDate, toDate := filter.From, filter.To
+	winRateOrder, ok := parseWinRateSort(c)
+	if !ok {
+		return
+	}
+	if winRateOrder == "" {
+		winRateOrder = "win_rate"
+	}
+	smooth := 0
+	if raw := c.Query("smooth"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > metaMaxSmoothWindow {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("smooth должен быть от 1 до %d", metaMaxSmoothWindow)})
 			return
 		}
-		end := t.Add(24*time.Hour - time.Nanosecond).UTC()
-		toDate = &end
-	}
-	if fromDate != nil && toDate != nil && fromDate.After(*toDate) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "from должен быть <= to"})
-		return
+		if v > 1 {
+			smooth = v
+		}
 	}
 
 	db := database.GetDB()
-	whereClause, whereArgs := buildCompletedGamesWhereClause("g", fromDate, toDate)
-	periodExpr := periodKeySQLExpr("g.start_time", groupBy)
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	periodExpr := periodKeySQLExpr("g.start_time", groupBy, filter.Timezone)
 
-	type deckAgg struct {
-		id    int
-		name  string
-		games int
-		wins  int
-	}
 	type periodDeckRow struct {
 		Period     string `gorm:"column:period"`
 		DeckID     int    `gorm:"column:deck_id"`
@@ -500,76 +624,94 @@ func GetMetaDashboard(c *gin.Context) {
 	}
 
 	var totalGames int64
-	if err := db.Table("games AS g").Where(whereClause, whereArgs...).Count(&totalGames).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить игры для мета-дашборда"})
-		return
-	}
-
-	periodTotalsQuery := fmt.Sprintf(`
-		SELECT
-			%s AS period,
-			COUNT(*) AS total_games
-		FROM games g
-		WHERE %s
-		GROUP BY period
-	`, periodExpr, whereClause)
 	var periodTotals []periodTotalRow
-	if err := db.Raw(periodTotalsQuery, whereArgs...).Scan(&periodTotals).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать периоды мета-дашборда"})
-		return
-	}
+	var periodDeckRows []periodDeckRow
+	// Периоды в stats_periods разложены в настроенном часовом поясе; при переопределении tz — живой запрос.
+	configuredTimezone, _, _ := resolveConfiguredTimezone()
+	if useStatsAggregates(db, filter) && filter.Timezone == configuredTimezone {
+		if err := db.Table("stats_periods").Where("group_by = ?", groupBy).
+			Select("period, total_games").Scan(&periodTotals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать периоды мета-дашборда"})
+			return
+		}
+		for _, r := range periodTotals {
+			totalGames += int64(r.TotalGames)
+		}
+		if err := db.Table("stats_period_decks").Where("group_by = ?", groupBy).
+			Select("period, deck_id, deck_name, games_count, wins_count").Scan(&periodDeckRows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать статистику колод для мета-дашборда"})
+			return
+		}
+	} else {
+		if err := db.Table("games AS g").Where(whereClause, whereArgs...).Count(&totalGames).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить игры для мета-дашборда"})
+			return
+		}
 
-	periodDecksQuery := fmt.Sprintf(`
-		WITH ranked_players AS (
+		periodTotalsQuery := fmt.Sprintf(`
 			SELECT
 				%s AS period,
-				gp.deck_id,
-				gp.deck_name,
-				g.winning_team,
-				ROW_NUMBER() OVER (PARTITION BY gp.game_id ORDER BY gp.id) AS player_index,
-				COUNT(*) OVER (PARTITION BY gp.game_id) AS players_count
-			FROM game_players gp
-			JOIN games g ON g.id = gp.game_id
+				COUNT(*) AS total_games
+			FROM games g
 			WHERE %s
-		)
-		SELECT
-			period,
-			deck_id,
-			MAX(deck_name) AS deck_name,
-			COUNT(*) AS games_count,
-			SUM(
-				CASE
-					WHEN (CASE WHEN player_index <= (players_count / 2) THEN 1 ELSE 2 END) = winning_team
-					THEN 1 ELSE 0
-				END
-			) AS wins_count
-		FROM ranked_players
-		GROUP BY period, deck_id
-	`, periodExpr, whereClause)
-	var periodDeckRows []periodDeckRow
-	if err := db.Raw(periodDecksQuery, whereArgs...).Scan(&periodDeckRows).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать статистику колод для мета-дашборда"})
-		return
+			GROUP BY period
+		`, periodExpr, whereClause)
+		if err := db.Raw(periodTotalsQuery, whereArgs...).Scan(&periodTotals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать периоды мета-дашборда"})
+			return
+		}
+
+		periodDecksQuery := fmt.Sprintf(`
+			WITH ranked_players AS (
+				SELECT
+					%s AS period,
+					gp.deck_id,
+					gp.deck_name,
+					g.winning_team,
+					ROW_NUMBER() OVER (PARTITION BY gp.game_id ORDER BY gp.id) AS player_index,
+					COUNT(*) OVER (PARTITION BY gp.game_id) AS players_count
+				FROM game_players gp
+				JOIN games g ON g.id = gp.game_id
+				WHERE %s
+			)
+			SELECT
+				period,
+				deck_id,
+				MAX(deck_name) AS deck_name,
+				COUNT(*) AS games_count,
+				SUM(
+					CASE
+						WHEN (CASE WHEN player_index <= (players_count / 2) THEN 1 ELSE 2 END) = winning_team
+						THEN 1 ELSE 0
+					END
+				) AS wins_count
+			FROM ranked_players
+			GROUP BY period, deck_id
+		`, periodExpr, whereClause)
+		if err := db.Raw(periodDecksQuery, whereArgs...).Scan(&periodDeckRows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось рассчитать статистику колод для мета-дашборда"})
+			return
+		}
 	}
 
-	allDecks := make(map[int]*deckAgg)
-	periodDecks := make(map[string]map[int]*deckAgg, len(periodTotals))
+	allDecks := make(map[int]*metaDeckAgg)
+	periodDecks := make(map[string]map[int]*metaDeckAgg, len(periodTotals))
 	periodTotalsByKey := make(map[string]int, len(periodTotals))
 	for _, r := range periodTotals {
 		periodTotalsByKey[r.Period] = r.TotalGames
-		periodDecks[r.Period] = make(map[int]*deckAgg)
+		periodDecks[r.Period] = make(map[int]*metaDeckAgg)
 	}
 	for _, r := range periodDeckRows {
 		if allDecks[r.DeckID] == nil {
-			allDecks[r.DeckID] = &deckAgg{id: r.DeckID, name: r.DeckName}
+			allDecks[r.DeckID] = &metaDeckAgg{id: r.DeckID, name: r.DeckName}
 		}
 		allDecks[r.DeckID].games += r.GamesCount
 		allDecks[r.DeckID].wins += r.WinsCount
 
 		if periodDecks[r.Period] == nil {
-			periodDecks[r.Period] = make(map[int]*deckAgg)
+			periodDecks[r.Period] = make(map[int]*metaDeckAgg)
 		}
-		periodDecks[r.Period][r.DeckID] = &deckAgg{
+		periodDecks[r.Period][r.DeckID] = &metaDeckAgg{
 			id:    r.DeckID,
 			name:  r.DeckName,
 			games: r.GamesCount,
@@ -577,31 +719,41 @@ func GetMetaDashboard(c *gin.Context) {
 		}
 	}
 
-	toMetaDeck := func(a *deckAgg, total int) models.MetaDeckStat {
-		winRate := 0.0
-		metaShare := 0.0
-		if a.games > 0 {
-			winRate = float64(a.wins) / float64(a.games) * 100
-		}
-		if total > 0 {
-			metaShare = float64(a.games) / float64(total) * 100
+	deckRatings, err := loadDeckRatings(db)
+	if err != nil {
+		log.Printf("GetMetaDashboard: ratings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить рейтинги колод"})
+		return
+	}
+
+	toMetaDeck := func(a *metaDeckAgg, total int) models.MetaDeckStat {
+		stat := models.MetaDeckStat{
+			DeckID:            a.id,
+			DeckName:          a.name,
+			GamesCount:        a.games,
+			WinsCount:         a.wins,
+			WinRate:           winPercent(a.wins, a.games),
+			MetaShare:         metaShare(a, total),
+			WinRateConfidence: winRateConfidence(a.wins, a.games),
 		}
-		return models.MetaDeckStat{
-			DeckID:     a.id,
-			DeckName:   a.name,
-			GamesCount: a.games,
-			WinsCount:  a.wins,
-			WinRate:    winRate,
-			MetaShare:  metaShare,
+		if rating, ok := deckRatings[a.id]; ok {
+			stat.Rating = &rating.Rating
+			stat.RatingDeviation = &rating.Deviation
 		}
+		return stat
 	}
 
 	topPlayed := make([]models.MetaDeckStat, 0, len(allDecks))
 	topWinRate := make([]models.MetaDeckStat, 0, len(allDecks))
+	minGames := filter.minGamesOr(1)
+	minWinRateGames := filter.minGamesOr(metaTopWinRateMinGames)
 	for _, a := range allDecks {
 		stat := toMetaDeck(a, int(totalGames))
+		if stat.GamesCount < minGames {
+			continue
+		}
 		topPlayed = append(topPlayed, stat)
-		if stat.GamesCount >= 3 {
+		if stat.GamesCount >= minWinRateGames {
 			topWinRate = append(topWinRate, stat)
 		}
 	}
@@ -612,11 +764,11 @@ func GetMetaDashboard(c *gin.Context) {
 		return topPlayed[i].DeckName < topPlayed[j].DeckName
 	})
 	sort.Slice(topWinRate, func(i, j int) bool {
-		if topWinRate[i].WinRate != topWinRate[j].WinRate {
-			return topWinRate[i].WinRate > topWinRate[j].WinRate
-		}
 		return topWinRate[i].DeckName < topWinRate[j].DeckName
 	})
+	sortByWinRate(winRateOrder, topWinRate, func(i int) (int, int) {
+		return topWinRate[i].WinsCount, topWinRate[i].GamesCount
+	})
 	if len(topPlayed) > 10 {
 		topPlayed = topPlayed[:10]
 	}
@@ -631,11 +783,41 @@ func GetMetaDashboard(c *gin.Context) {
 	sort.Strings(periodKeys)
 
 	periodOut := make([]models.MetaPeriodStats, 0, len(periodKeys))
-	for _, key := range periodKeys {
+	for i, key := range periodKeys {
 		total := periodTotalsByKey[key]
 		decks := make([]models.MetaDeckStat, 0, len(periodDecks[key]))
 		for _, a := range periodDecks[key] {
-			decks = append(decks, toMetaDeck(a, total))
+			stat := toMetaDeck(a, total)
+			if i > 0 {
+				prevKey := periodKeys[i-1]
+				shareDelta := stat.MetaShare - metaShare(periodDecks[prevKey][a.id], periodTotalsByKey[prevKey])
+				stat.MetaShareDelta = &shareDelta
+				if prev := periodDecks[prevKey][a.id]; prev != nil && prev.games > 0 {
+					winRateDelta := stat.WinRate - float64(prev.wins)/float64(prev.games)*100
+					stat.WinRateDelta = &winRateDelta
+				}
+			}
+			if smooth > 0 {
+				// Доля меты — среднее долей по окну (0 в периодах без колоды), процент побед — по сумме игр окна.
+				var shareSum float64
+				var games, wins int
+				start := i - smooth + 1
+				if start < 0 {
+					start = 0
+				}
+				for _, k := range periodKeys[start : i+1] {
+					d := periodDecks[k][a.id]
+					shareSum += metaShare(d, periodTotalsByKey[k])
+					if d != nil {
+						games += d.games
+						wins += d.wins
+					}
+				}
+				smoothedShare := shareSum / float64(i+1-start)
+				smoothedWinRate := float64(wins) / float64(games) * 100
+				stat.SmoothedMetaShare, stat.SmoothedWinRate = &smoothedShare, &smoothedWinRate
+			}
+			decks = append(decks, stat)
 		}
 		sort.Slice(decks, func(i, j int) bool {
 			if decks[i].GamesCount != decks[j].GamesCount {
@@ -650,50 +832,135 @@ func GetMetaDashboard(c *gin.Context) {
 		})
 	}
 
+	rising, falling := []models.MetaTrendDeck{}, []models.MetaTrendDeck{}
+	if n := len(periodKeys); n >= 2 {
+		key, prevKey := periodKeys[n-1], periodKeys[n-2]
+		seen := make(map[int]bool)
+		for _, decks := range []map[int]*metaDeckAgg{periodDecks[key], periodDecks[prevKey]} {
+			for id := range decks {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				cur, prev := periodDecks[key][id], periodDecks[prevKey][id]
+				trend := models.MetaTrendDeck{
+					DeckID:            id,
+					DeckName:          allDecks[id].name,
+					Period:            key,
+					PreviousPeriod:    prevKey,
+					MetaShare:         metaShare(cur, periodTotalsByKey[key]),
+					PreviousMetaShare: metaShare(prev, periodTotalsByKey[prevKey]),
+				}
+				trend.MetaShareDelta = trend.MetaShare - trend.PreviousMetaShare
+				if cur != nil {
+					trend.GamesCount += cur.games
+					winRate := float64(cur.wins) / float64(cur.games) * 100
+					trend.WinRate = &winRate
+					if prev != nil {
+						delta := winRate - float64(prev.wins)/float64(prev.games)*100
+						trend.WinRateDelta = &delta
+					}
+				}
+				if prev != nil {
+					trend.GamesCount += prev.games
+				}
+				if trend.GamesCount < minGames {
+					continue
+				}
+				if trend.MetaShareDelta > 0 {
+					rising = append(rising, trend)
+				} else if trend.MetaShareDelta < 0 {
+					falling = append(falling, trend)
+				}
+			}
+		}
+		sort.Slice(rising, func(i, j int) bool {
+			if rising[i].MetaShareDelta != rising[j].MetaShareDelta {
+				return rising[i].MetaShareDelta > rising[j].MetaShareDelta
+			}
+			return rising[i].DeckName < rising[j].DeckName
+		})
+		sort.Slice(falling, func(i, j int) bool {
+			if falling[i].MetaShareDelta != falling[j].MetaShareDelta {
+				return falling[i].MetaShareDelta < falling[j].MetaShareDelta
+			}
+			return falling[i].DeckName < falling[j].DeckName
+		})
+		if len(rising) > metaTrendDecks {
+			rising = rising[:metaTrendDecks]
+		}
+		if len(falling) > metaTrendDecks {
+			falling = falling[:metaTrendDecks]
+		}
+	}
+
 	resp := models.MetaDashboardResponse{
 		GroupBy:         groupBy,
+		Timezone:        filter.Timezone,
 		TotalGames:      int(totalGames),
 		UniqueDecks:     len(allDecks),
 		TopPlayedDecks:  topPlayed,
 		TopWinRateDecks: topWinRate,
 		Periods:         periodOut,
+		SmoothWindow:    smooth,
+		TopRisingDecks:  rising,
+		TopFallingDecks: falling,
 	}
 	if fromDate != nil {
-		resp.FromDate = fromDate.Format("2006-01-02")
+		resp.FromDate = fromDate.In(filter.Location).Format("2006-01-02")
 	}
 	if toDate != nil {
-		resp.ToDate = toDate.Format("2006-01-02")
+		resp.ToDate = toDate.In(filter.Location).Format("2006-01-02")
 	}
 	writeStatsCacheJSON(c, resp)
 }
 
-func periodKeySQLExpr(column, groupBy string) string {
+// periodKeySQLExpr — SQL-выражение ключа периода для колонки column в часовом поясе timezone (имя IANA,
+// уже проверенное time.LoadLocation).
+func periodKeySQLExpr(column, groupBy, timezone string) string {
+	local := fmt.Sprintf("(%s AT TIME ZONE '%s')", column, strings.ReplaceAll(timezone, "'", "''"))
 	switch groupBy {
 	case "day":
-		return fmt.Sprintf("to_char((%s AT TIME ZONE 'UTC'), 'YYYY-MM-DD')", column)
+		return fmt.Sprintf("to_char(%s, 'YYYY-MM-DD')", local)
 	case "month":
-		return fmt.Sprintf("to_char((%s AT TIME ZONE 'UTC'), 'YYYY-MM')", column)
+		return fmt.Sprintf("to_char(%s, 'YYYY-MM')", local)
 	default:
-		return fmt.Sprintf("to_char((%s AT TIME ZONE 'UTC'), 'IYYY') || 'W' || to_char((%s AT TIME ZONE 'UTC'), 'IW')", column, column)
+		return fmt.Sprintf("to_char(%s, 'IYYY') || 'W' || to_char(%s, 'IW')", local, local)
 	}
 }
 
-func buildCompletedGamesWhereClause(alias string, fromDate, toDate *time.Time) (string, []interface{}) {
-	where := fmt.Sprintf("%s.end_time IS NOT NULL AND %s.winning_team IS NOT NULL", alias, alias)
-	args := make([]interface{}, 0, 2)
-	if fromDate != nil {
-		where += fmt.Sprintf(" AND %s.start_time >= ?", alias)
-		args = append(args, *fromDate)
-	}
-	if toDate != nil {
-		where += fmt.Sprintf(" AND %s.start_time <= ?", alias)
-		args = append(args, *toDate)
-	}
-	return where, args
+// playersWithTeamCTE — CTE ranked_players и players_with_team: участники завершённых игр с командой.
+// Команда выводится по порядку добавления игрока (gp.id): первая половина — 1, вторая — 2.
+// where — условие на games g (см. buildCompletedGamesWhereClause); результат вставляется после WITH.
+func playersWithTeamCTE(where string) string {
+	return fmt.Sprintf(`ranked_players AS (
+			SELECT
+				gp.game_id,
+				gp.user_id,
+				gp.deck_id,
+				gp.deck_name,
+				u.name AS player_name,
+				g.start_time,
+				g.end_time,
+				g.winning_team,
+				g.first_move_team,
+				ROW_NUMBER() OVER (PARTITION BY gp.game_id ORDER BY gp.id) AS player_index,
+				COUNT(*) OVER (PARTITION BY gp.game_id) AS players_count
+			FROM game_players gp
+			JOIN games g ON g.id = gp.game_id
+			JOIN users u ON u.id = gp.user_id
+			WHERE %s
+		),
+		players_with_team AS (
+			SELECT
+				*,
+				CASE WHEN player_index <= (players_count / 2) THEN 1 ELSE 2 END AS player_team
+			FROM ranked_players
+		)`, where)
 }
 
 func writeStatsCacheHit(c *gin.Context) bool {
-	if payload, ok := statsResponseCache.Get(c.Request.URL.RequestURI()); ok {
+	if payload, ok := statsResponseCache.Get(statsCacheKey(c)); ok {
 		c.Data(http.StatusOK, "application/json; charset=utf-8", payload)
 		return true
 	}
@@ -701,7 +968,7 @@ func writeStatsCacheHit(c *gin.Context) bool {
 }
 
 func writeStatsCacheJSON(c *gin.Context, payload interface{}) {
-	key := c.Request.URL.RequestURI()
+	key := statsCacheKey(c)
 	if err := statsResponseCache.SetJSON(key, payload); err != nil {
 		c.JSON(http.StatusOK, payload)
 		return
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// metaGroupBys — группировки мета-дашборда, для которых ведутся агрегаты по периодам.
+var metaGroupBys = []string{"day", "week", "month"}
+
+type playerDeckKey struct {
+	userID uint
+	deckID int
+}
+
+type deckPairKey struct {
+	deck1ID, deck2ID int
+}
+
+type periodAggKey struct {
+	groupBy, period string
+}
+
+type periodDeckKey struct {
+	groupBy, period string
+	deckID          int
+}
+
+// statsAggregator — накопление агрегатов статистики по играм в хронологическом порядке.
+// Используется и для инкрементального обновления (после загрузки затронутых строк), и для полной пересборки.
+// loc — часовой пояс, в котором игры раскладываются по дням, неделям и месяцам.
+type statsAggregator struct {
+	loc         *time.Location
+	players     map[uint]*models.StatsPlayerAggregate
+	playerDecks map[playerDeckKey]*models.StatsPlayerDeckAggregate
+	decks       map[int]*models.StatsDeckAggregate
+	matchups    map[deckPairKey]*models.StatsDeckMatchupAggregate
+	periods     map[periodAggKey]*models.StatsPeriodAggregate
+	periodDecks map[periodDeckKey]*models.StatsPeriodDeckAggregate
+}
+
+func newStatsAggregator(loc *time.Location) *statsAggregator {
+	return &statsAggregator{
+		loc:         loc,
+		players:     make(map[uint]*models.StatsPlayerAggregate),
+		playerDecks: make(map[playerDeckKey]*models.StatsPlayerDeckAggregate),
+		decks:       make(map[int]*models.StatsDeckAggregate),
+		matchups:    make(map[deckPairKey]*models.StatsDeckMatchupAggregate),
+		periods:     make(map[periodAggKey]*models.StatsPeriodAggregate),
+		periodDecks: make(map[periodDeckKey]*models.StatsPeriodDeckAggregate),
+	}
+}
+
+// load подгружает сохранённые строки, которые затронет игра g (для инкрементального обновления).
+// Составные ключи загружаются с запасом (IN по каждой колонке) — лишние строки сохраняются без изменений.
+func (a *statsAggregator) load(db *gorm.DB, g models.Game) error {
+	userIDs := make([]uint, 0, len(g.Players))
+	deckIDs := make([]int, 0, len(g.Players))
+	for _, p := range g.Players {
+		userIDs = append(userIDs, p.UserID)
+		deckIDs = append(deckIDs, p.DeckID)
+	}
+	periods := make([]string, 0, len(metaGroupBys))
+	for _, groupBy := range metaGroupBys {
+		periods = append(periods, periodKey(g.StartTime, groupBy, a.loc))
+	}
+
+	var players []models.StatsPlayerAggregate
+	if err := db.Where("user_id IN ?", userIDs).Find(&players).Error; err != nil {
+		return err
+	}
+	for i := range players {
+		a.players[players[i].UserID] = &players[i]
+	}
+	var playerDecks []models.StatsPlayerDeckAggregate
+	if err := db.Where("user_id IN ? AND deck_id IN ?", userIDs, deckIDs).Find(&playerDecks).Error; err != nil {
+		return err
+	}
+	for i := range playerDecks {
+		a.playerDecks[playerDeckKey{playerDecks[i].UserID, playerDecks[i].DeckID}] = &playerDecks[i]
+	}
+	var decks []models.StatsDeckAggregate
+	if err := db.Where("deck_id IN ?", deckIDs).Find(&decks).Error; err != nil {
+		return err
+	}
+	for i := range decks {
+		a.decks[decks[i].DeckID] = &decks[i]
+	}
+	var matchups []models.StatsDeckMatchupAggregate
+	if err := db.Where("deck1_id IN ? AND deck2_id IN ?", deckIDs, deckIDs).Find(&matchups).Error; err != nil {
+		return err
+	}
+	for i := range matchups {
+		a.matchups[deckPairKey{matchups[i].Deck1ID, matchups[i].Deck2ID}] = &matchups[i]
+	}
+	var periodRows []models.StatsPeriodAggregate
+	if err := db.Where("period IN ?", periods).Find(&periodRows).Error; err != nil {
+		return err
+	}
+	for i := range periodRows {
+		a.periods[periodAggKey{periodRows[i].GroupBy, periodRows[i].Period}] = &periodRows[i]
+	}
+	var periodDecks []models.StatsPeriodDeckAggregate
+	if err := db.Where("period IN ? AND deck_id IN ?", periods, deckIDs).Find(&periodDecks).Error; err != nil {
+		return err
+	}
+	for i := range periodDecks {
+		r := &periodDecks[i]
+		a.periodDecks[periodDeckKey{r.GroupBy, r.Period, r.DeckID}] = r
+	}
+	return nil
+}
+
+func (a *statsAggregator) player(userID uint) *models.StatsPlayerAggregate {
+	if r := a.players[userID]; r != nil {
+		return r
+	}
+	r := &models.StatsPlayerAggregate{UserID: userID}
+	a.players[userID] = r
+	return r
+}
+
+func (a *statsAggregator) deck(deckID int) *models.StatsDeckAggregate {
+	if r := a.decks[deckID]; r != nil {
+		return r
+	}
+	r := &models.StatsDeckAggregate{DeckID: deckID}
+	a.decks[deckID] = r
+	return r
+}
+
+// applyGame добавляет завершённую игру в агрегаты. Команды — как в playersWithTeamCTE (playerTeam).
+// Серии считаются по порядку вызовов, поэтому игры должны идти по end_time.
+func (a *statsAggregator) applyGame(g models.Game) {
+	if g.EndTime == nil || g.WinningTeam == nil {
+		return
+	}
+	winningTeam := *g.WinningTeam
+	players := sortedGamePlayers(g.Players)
+
+	var turnsCount [3]int
+	var turnsTotal [3]int64
+	var turnsMax [3]int
+	for _, t := range g.Turns {
+		if t.TeamNumber < 1 || t.TeamNumber > 2 {
+			continue
+		}
+		turnsCount[t.TeamNumber]++
+		turnsTotal[t.TeamNumber] += int64(t.Duration)
+		if t.Duration > turnsMax[t.TeamNumber] {
+			turnsMax[t.TeamNumber] = t.Duration
+		}
+	}
+
+	var seats [3][]models.GamePlayer
+	for i, p := range players {
+		team := playerTeam(i, len(players))
+		seats[team] = append(seats[team], p)
+		won := team == winningTeam
+		end := *g.EndTime
+
+		pl := a.player(p.UserID)
+		pl.GamesCount++
+		if won {
+			pl.WinsCount++
+			pl.CurrentWinStreak++
+			pl.CurrentLossStreak = 0
+			if pl.CurrentWinStreak > pl.MaxWinStreak {
+				pl.MaxWinStreak = pl.CurrentWinStreak
+			}
+		} else {
+			pl.CurrentLossStreak++
+			pl.CurrentWinStreak = 0
+			if pl.CurrentLossStreak > pl.MaxLossStreak {
+				pl.MaxLossStreak = pl.CurrentLossStreak
+			}
+		}
+		if team == g.FirstMoveTeam {
+			pl.FirstMoveGames++
+			if won {
+				pl.FirstMoveWins++
+			}
+		}
+		pl.TurnsCount += turnsCount[team]
+		pl.TurnsDurationSec += turnsTotal[team]
+		if turnsMax[team] > pl.MaxTurnDurationSec {
+			pl.MaxTurnDurationSec = turnsMax[team]
+		}
+		pl.LastGameAt = &end
+
+		key := playerDeckKey{p.UserID, p.DeckID}
+		pd := a.playerDecks[key]
+		if pd == nil {
+			pd = &models.StatsPlayerDeckAggregate{UserID: p.UserID, DeckID: p.DeckID}
+			a.playerDecks[key] = pd
+		}
+		pd.DeckName = p.DeckName
+		pd.GamesCount++
+		if won {
+			pd.WinsCount++
+		}
+		pd.TurnsCount += turnsCount[team]
+		pd.TurnsDurationSec += turnsTotal[team]
+		if end.After(pd.LastPlayedAt) {
+			pd.LastPlayedAt = end
+		}
+
+		d := a.deck(p.DeckID)
+		d.DeckName = p.DeckName
+		d.GamesCount++
+		if won {
+			d.WinsCount++
+		}
+
+		for _, groupBy := range metaGroupBys {
+			pk := periodDeckKey{groupBy, periodKey(g.StartTime, groupBy, a.loc), p.DeckID}
+			pdk := a.periodDecks[pk]
+			if pdk == nil {
+				pdk = &models.StatsPeriodDeckAggregate{GroupBy: pk.groupBy, Period: pk.period, DeckID: p.DeckID}
+				a.periodDecks[pk] = pdk
+			}
+			pdk.DeckName = p.DeckName
+			pdk.GamesCount++
+			if won {
+				pdk.WinsCount++
+			}
+		}
+	}
+
+	for _, groupBy := range metaGroupBys {
+		key := periodAggKey{groupBy, periodKey(g.StartTime, groupBy, a.loc)}
+		pr := a.periods[key]
+		if pr == nil {
+			pr = &models.StatsPeriodAggregate{GroupBy: key.groupBy, Period: key.period}
+			a.periods[key] = pr
+		}
+		pr.TotalGames++
+	}
+
+	// Матчапы — все пары «игрок команды 1 × игрок команды 2», нормализованные как в GetDeckMatchups.
+	for _, p1 := range seats[1] {
+		for _, p2 := range seats[2] {
+			deck1, deck2 := p1, p2
+			deck1Won := winningTeam == 1
+			if p1.DeckID > p2.DeckID {
+				deck1, deck2 = p2, p1
+				deck1Won = winningTeam == 2
+			}
+			key := deckPairKey{deck1.DeckID, deck2.DeckID}
+			m := a.matchups[key]
+			if m == nil {
+				m = &models.StatsDeckMatchupAggregate{Deck1ID: key.deck1ID, Deck2ID: key.deck2ID}
+				a.matchups[key] = m
+			}
+			m.Deck1Name = deck1.DeckName
+			m.Deck2Name = deck2.DeckName
+			m.GamesCount++
+			if deck1Won {
+				m.Deck1Wins++
+			}
+		}
+	}
+}
+
+// rows — все накопленные строки для сохранения, с отметкой времени now.
+func (a *statsAggregator) rows(now time.Time) (
+	players []models.StatsPlayerAggregate,
+	playerDecks []models.StatsPlayerDeckAggregate,
+	decks []models.StatsDeckAggregate,
+	matchups []models.StatsDeckMatchupAggregate,
+	periods []models.StatsPeriodAggregate,
+	periodDecks []models.StatsPeriodDeckAggregate,
+) {
+	for _, r := range a.players {
+		r.UpdatedAt = now
+		players = append(players, *r)
+	}
+	for _, r := range a.playerDecks {
+		r.UpdatedAt = now
+		playerDecks = append(playerDecks, *r)
+	}
+	for _, r := range a.decks {
+		r.UpdatedAt = now
+		decks = append(decks, *r)
+	}
+	for _, r := range a.matchups {
+		r.UpdatedAt = now
+		matchups = append(matchups, *r)
+	}
+	for _, r := range a.periods {
+		r.UpdatedAt = now
+		periods = append(periods, *r)
+	}
+	for _, r := range a.periodDecks {
+		r.UpdatedAt = now
+		periodDecks = append(periodDecks, *r)
+	}
+	return
+}
+
+// save сохраняет строки агрегатора: upsert для инкрементального обновления, пакетная вставка — после очистки.
+func (a *statsAggregator) save(tx *gorm.DB, upsert bool) error {
+	players, playerDecks, decks, matchups, periods, periodDecks := a.rows(time.Now().UTC())
+	for _, batch := range []interface{}{&players, &playerDecks, &decks, &matchups, &periods, &periodDecks} {
+		q := tx
+		if upsert {
+			q = tx.Clauses(clause.OnConflict{UpdateAll: true})
+		}
+		if err := q.CreateInBatches(batch, 500).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statsAggregateTables — таблицы агрегатов статистики (для очистки и пересборки).
+var statsAggregateTables = []string{
+	"stats_players",
+	"stats_player_decks",
+	"stats_decks",
+	"stats_deck_matchups",
+	"stats_periods",
+	"stats_period_decks",
+}
+
+// clearStatsAggregates очищает все таблицы агрегатов статистики.
+func clearStatsAggregates(tx *gorm.DB) error {
+	for _, table := range statsAggregateTables {
+		if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyGameAggregates — инкрементальное обновление агрегатов после завершения игры (в транзакции FinishGame).
+// Как и applyGameRatings, корректно для самой поздней игры; правки задним числом требуют rebuildStatsAggregates.
+func applyGameAggregates(tx *gorm.DB, gameID uint) error {
+	var game models.Game
+	if err := tx.Preload("Players").Preload("Turns").First(&game, gameID).Error; err != nil {
+		return err
+	}
+	if game.EndTime == nil || game.WinningTeam == nil {
+		return nil
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	agg := newStatsAggregator(loc)
+	if err := agg.load(tx, game); err != nil {
+		return err
+	}
+	agg.applyGame(game)
+	return agg.save(tx, true)
+}
+
+// rebuildStatsAggregates — полная пересборка агрегатов по всем завершённым играм. Возвращает число учтённых игр.
+func rebuildStatsAggregates(tx *gorm.DB) (int, error) {
+	_, loc, _ := resolveConfiguredTimezone()
+	agg, games, err := aggregateCompletedGames(tx, loc)
+	if err != nil {
+		return 0, err
+	}
+	if err := clearStatsAggregates(tx); err != nil {
+		return 0, err
+	}
+	if err := agg.save(tx, false); err != nil {
+		return 0, err
+	}
+	mark := models.AppSetting{Key: models.AppSettingStatsAggregatesKey, Value: time.Now().UTC().Format(time.RFC3339)}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&mark).Error; err != nil {
+		return 0, err
+	}
+	return games, nil
+}
+
+// aggregateCompletedGames прогоняет все завершённые игры через агрегатор. Возвращает агрегатор и число игр.
+func aggregateCompletedGames(tx *gorm.DB, loc *time.Location) (*statsAggregator, int, error) {
+	var games []models.Game
+	if err := tx.Where("end_time IS NOT NULL AND winning_team IS NOT NULL").
+		Order("end_time ASC, id ASC").
+		Preload("Players").
+		Preload("Turns").
+		Find(&games).Error; err != nil {
+		return nil, 0, err
+	}
+	agg := newStatsAggregator(loc)
+	for _, g := range games {
+		agg.applyGame(g)
+	}
+	return agg, len(games), nil
+}
+
+// rebuildPeriodAggregates пересобирает только stats_periods и stats_period_decks в часовом поясе loc
+// (после смены часового пояса в настройках: границы дней и недель сдвигаются, остальные агрегаты от них не зависят).
+func rebuildPeriodAggregates(tx *gorm.DB, loc *time.Location) error {
+	agg, _, err := aggregateCompletedGames(tx, loc)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"stats_periods", "stats_period_decks"} {
+		if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+			return err
+		}
+	}
+	_, _, _, _, periods, periodDecks := agg.rows(time.Now().UTC())
+	if err := tx.CreateInBatches(&periods, 500).Error; err != nil {
+		return err
+	}
+	return tx.CreateInBatches(&periodDecks, 500).Error
+}
+
+// statsAggregatesReady — агрегаты хотя бы раз собраны полностью; до этого статистика читается живыми запросами.
+func statsAggregatesReady(db *gorm.DB) bool {
+	var count int64
+	db.Model(&models.AppSetting{}).Where("key = ?", models.AppSettingStatsAggregatesKey).Count(&count)
+	return count > 0
+}
+
+// useStatsAggregates — можно ли ответить из агрегатов: фильтры не заданы (кроме min_games) и агрегаты собраны.
+func useStatsAggregates(db *gorm.DB, f statsFilter) bool {
+	return f.isEmpty() && statsAggregatesReady(db)
+}
+
+// RebuildStatsAggregates — полная пересборка агрегатов в отдельной транзакции (cmd/rebuildstats и старт сервера).
+func RebuildStatsAggregates(db *gorm.DB) (int, error) {
+	var games int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		games, err = rebuildStatsAggregates(tx)
+		return err
+	})
+	if err == nil {
+		invalidateStatsCache()
+	}
+	return games, err
+}
+
+// EnsureStatsAggregates собирает агрегаты при первом запуске с ними (история уже есть, таблицы пусты).
+func EnsureStatsAggregates(db *gorm.DB) {
+	if statsAggregatesReady(db) {
+		return
+	}
+	games, err := RebuildStatsAggregates(db)
+	if err != nil {
+		log.Printf("Агрегаты статистики не собраны, статистика читается живыми запросами: %v", err)
+		return
+	}
+	log.Printf("Агрегаты статистики собраны: %d игр", games)
+}
+
+// RebuildStats — полная пересборка агрегатов статистики (только админ).
+func RebuildStats(c *gin.Context) {
+	games, err := RebuildStatsAggregates(database.GetDB())
+	if err != nil {
+		log.Printf("RebuildStats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось пересобрать агрегаты статистики"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Агрегаты статистики пересобраны", "games": games})
+}
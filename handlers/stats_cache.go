@@ -22,7 +22,7 @@ type statsCacheStore struct {
 
 func newStatsCacheStore() *statsCacheStore {
 	return &statsCacheStore{
-		ttl:     time.Duration(envIntSeconds("STATS_CACHE_TTL_SECONDS", 15)) * time.Second,
+		ttl:     time.Duration(envInt("STATS_CACHE_TTL_SECONDS", 15)) * time.Second,
 		entries: make(map[string]statsCacheEntry),
 	}
 }
@@ -63,7 +63,8 @@ func (s *statsCacheStore) InvalidateAll() {
 	s.mu.Unlock()
 }
 
-func envIntSeconds(key string, fallback int) int {
+// envInt — положительное целое из переменной окружения key; при пустом или некорректном значении — fallback.
+func envInt(key string, fallback int) int {
 	raw := strings.TrimSpace(os.Getenv(key))
 	if raw == "" {
 		return fallback
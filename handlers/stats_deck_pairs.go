@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deckPairsDefaultMinGames — порог игр по умолчанию, ниже которого пары и матчапы не показываются.
+const deckPairsDefaultMinGames = 3
+
+// deckTeamPairsCTE — CTE team_pairs: пары колод внутри одной команды, нормализованные по deck_id.
+const deckTeamPairsCTE = `team_pairs AS (
+			SELECT
+				a.game_id,
+				a.player_team,
+				a.winning_team,
+				a.players_count,
+				LEAST(a.deck_id, b.deck_id) AS deck1_id,
+				CASE WHEN a.deck_id <= b.deck_id THEN a.deck_name ELSE b.deck_name END AS deck1_name,
+				GREATEST(a.deck_id, b.deck_id) AS deck2_id,
+				CASE WHEN a.deck_id <= b.deck_id THEN b.deck_name ELSE a.deck_name END AS deck2_name
+			FROM players_with_team a
+			JOIN players_with_team b
+				ON b.game_id = a.game_id
+				AND b.player_team = a.player_team
+				AND b.player_index > a.player_index
+		)`
+
+// GetDeckPairs — синергия колод в одной команде и матчапы составов 2v2 (пара против пары).
+// В отличие от GetDeckMatchups учитываются пары внутри команды; min_games (по умолчанию 3) отсекает шум.
+func GetDeckPairs(c *gin.Context) {
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	minGames := filter.minGamesOr(deckPairsDefaultMinGames)
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	args := append(append([]interface{}{}, whereArgs...), minGames)
+
+	type pairRow struct {
+		Deck1ID    int    `gorm:"column:deck1_id"`
+		Deck1Name  string `gorm:"column:deck1_name"`
+		Deck2ID    int    `gorm:"column:deck2_id"`
+		Deck2Name  string `gorm:"column:deck2_name"`
+		GamesCount int    `gorm:"column:games_count"`
+		WinsCount  int    `gorm:"column:wins_count"`
+	}
+	pairsQuery := fmt.Sprintf(`
+		WITH %s,
+		%s
+		SELECT
+			deck1_id,
+			MAX(deck1_name) AS deck1_name,
+			deck2_id,
+			MAX(deck2_name) AS deck2_name,
+			COUNT(*) AS games_count,
+			SUM(CASE WHEN player_team = winning_team THEN 1 ELSE 0 END) AS wins_count
+		FROM team_pairs
+		GROUP BY deck1_id, deck2_id
+		HAVING COUNT(*) >= ?
+	`, playersWithTeamCTE(whereClause), deckTeamPairsCTE)
+	var pairRows []pairRow
+	if err := db.Raw(pairsQuery, args...).Scan(&pairRows).Error; err != nil {
+		log.Printf("GetDeckPairs: pairs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить пары колод"})
+		return
+	}
+
+	type matchupRow struct {
+		A1ID       int    `gorm:"column:a1_id"`
+		A1Name     string `gorm:"column:a1_name"`
+		A2ID       int    `gorm:"column:a2_id"`
+		A2Name     string `gorm:"column:a2_name"`
+		B1ID       int    `gorm:"column:b1_id"`
+		B1Name     string `gorm:"column:b1_name"`
+		B2ID       int    `gorm:"column:b2_id"`
+		B2Name     string `gorm:"column:b2_name"`
+		GamesCount int    `gorm:"column:games_count"`
+		PairAWins  int    `gorm:"column:pair_a_wins"`
+	}
+	// Только игры 2 на 2; сторона A — меньший по (deck1_id, deck2_id) состав.
+	matchupsQuery := fmt.Sprintf(`
+		WITH %s,
+		%s,
+		compositions AS (
+			SELECT
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t1.deck1_id ELSE t2.deck1_id END AS a1_id,
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t1.deck1_name ELSE t2.deck1_name END AS a1_name,
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t1.deck2_id ELSE t2.deck2_id END AS a2_id,
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t1.deck2_name ELSE t2.deck2_name END AS a2_name,
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t2.deck1_id ELSE t1.deck1_id END AS b1_id,
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t2.deck1_name ELSE t1.deck1_name END AS b1_name,
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t2.deck2_id ELSE t1.deck2_id END AS b2_id,
+				CASE WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id) THEN t2.deck2_name ELSE t1.deck2_name END AS b2_name,
+				CASE
+					WHEN ROW(t1.deck1_id, t1.deck2_id) <= ROW(t2.deck1_id, t2.deck2_id)
+						THEN CASE WHEN t1.winning_team = 1 THEN 1 ELSE 0 END
+					ELSE CASE WHEN t1.winning_team = 2 THEN 1 ELSE 0 END
+				END AS pair_a_win
+			FROM team_pairs t1
+			JOIN team_pairs t2
+				ON t2.game_id = t1.game_id
+				AND t1.player_team = 1
+				AND t2.player_team = 2
+			WHERE t1.players_count = 4
+		)
+		SELECT
+			a1_id,
+			MAX(a1_name) AS a1_name,
+			a2_id,
+			MAX(a2_name) AS a2_name,
+			b1_id,
+			MAX(b1_name) AS b1_name,
+			b2_id,
+			MAX(b2_name) AS b2_name,
+			COUNT(*) AS games_count,
+			SUM(pair_a_win) AS pair_a_wins
+		FROM compositions
+		GROUP BY a1_id, a2_id, b1_id, b2_id
+		HAVING COUNT(*) >= ?
+	`, playersWithTeamCTE(whereClause), deckTeamPairsCTE)
+	var matchupRows []matchupRow
+	if err := db.Raw(matchupsQuery, args...).Scan(&matchupRows).Error; err != nil {
+		log.Printf("GetDeckPairs: matchups: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить матчапы составов"})
+		return
+	}
+
+	resp := models.DeckPairsResponse{
+		MinGames: minGames,
+		Pairs:    make([]models.DeckPairStats, 0, len(pairRows)),
+		Matchups: make([]models.DeckPairMatchupStats, 0, len(matchupRows)),
+	}
+	for _, r := range pairRows {
+		resp.Pairs = append(resp.Pairs, models.DeckPairStats{
+			DeckPair:   models.DeckPair{Deck1ID: r.Deck1ID, Deck1Name: r.Deck1Name, Deck2ID: r.Deck2ID, Deck2Name: r.Deck2Name},
+			GamesCount: r.GamesCount,
+			WinsCount:  r.WinsCount,
+			WinRate:    winPercent(r.WinsCount, r.GamesCount),
+		})
+	}
+	for _, r := range matchupRows {
+		resp.Matchups = append(resp.Matchups, models.DeckPairMatchupStats{
+			PairA:        models.DeckPair{Deck1ID: r.A1ID, Deck1Name: r.A1Name, Deck2ID: r.A2ID, Deck2Name: r.A2Name},
+			PairB:        models.DeckPair{Deck1ID: r.B1ID, Deck1Name: r.B1Name, Deck2ID: r.B2ID, Deck2Name: r.B2Name},
+			GamesCount:   r.GamesCount,
+			PairAWins:    r.PairAWins,
+			PairBWins:    r.GamesCount - r.PairAWins,
+			PairAWinRate: winPercent(r.PairAWins, r.GamesCount),
+			PairBWinRate: winPercent(r.GamesCount-r.PairAWins, r.GamesCount),
+		})
+	}
+	sort.Slice(resp.Pairs, func(i, j int) bool {
+		if resp.Pairs[i].WinRate != resp.Pairs[j].WinRate {
+			return resp.Pairs[i].WinRate > resp.Pairs[j].WinRate
+		}
+		return resp.Pairs[i].GamesCount > resp.Pairs[j].GamesCount
+	})
+	sort.Slice(resp.Matchups, func(i, j int) bool {
+		return resp.Matchups[i].GamesCount > resp.Matchups[j].GamesCount
+	})
+	writeStatsCacheJSON(c, resp)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsFilter — общие фильтры эндпоинтов статистики (query-параметры):
+// from/to — дата начала игры (YYYY-MM-DD, to включительно); season_id — вместо from/to даты сезона;
+// players — игры, где участвовали все перечисленные; exclude_players — без любого из перечисленных;
+// decks — игры хотя бы с одной из колод; team_size — игроков в команде;
+// include_technical=false — без технических поражений; min_games — порог игр для строк агрегата;
+// tz — часовой пояс IANA для from/to и периодов (по умолчанию настроенный, см. resolveConfiguredTimezone).
+// Списки — через запятую или повтором параметра.
+type statsFilter struct {
+	Timezone         string
+	Location         *time.Location
+	From             *time.Time
+	To               *time.Time
+	Players          []uint
+	ExcludePlayers   []uint
+	Decks            []int
+	TeamSize         int
+	IncludeTechnical bool
+	MinGames         int
+}
+
+// isEmpty — фильтры не сужают выборку игр (min_games не учитывается: это порог строк агрегата).
+func (f statsFilter) isEmpty() bool {
+	return f.From == nil && f.To == nil && len(f.Players) == 0 && len(f.ExcludePlayers) == 0 &&
+		len(f.Decks) == 0 && f.TeamSize == 0 && f.IncludeTechnical
+}
+
+// minGamesOr — min_games из запроса или значение эндпоинта по умолчанию.
+func (f statsFilter) minGamesOr(def int) int {
+	if f.MinGames > 0 {
+		return f.MinGames
+	}
+	return def
+}
+
+// queryList — значения параметра: повторы и списки через запятую, без пустых.
+func queryList(c *gin.Context, key string) []string {
+	var out []string
+	for _, raw := range c.QueryArray(key) {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func parseUserIDList(c *gin.Context, key string) ([]uint, error) {
+	var ids []uint
+	for _, raw := range queryList(c, key) {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("Некорректный %s: %q", key, raw)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// parseStatsFilter разбирает общие фильтры статистики. При ошибке пишет 400 и возвращает ok == false.
+func parseStatsFilter(c *gin.Context) (statsFilter, bool) {
+	f := statsFilter{IncludeTechnical: true}
+	f.Timezone, f.Location, _ = resolveConfiguredTimezone()
+	if raw := strings.TrimSpace(c.Query("tz")); raw != "" {
+		loc, err := time.LoadLocation(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный tz (используйте IANA, например Europe/Moscow)"})
+			return f, false
+		}
+		f.Timezone, f.Location = raw, loc
+	}
+	from, to, ok := parseStatsDateRange(c, f.Location)
+	if !ok {
+		return f, false
+	}
+	f.From, f.To = from, to
+
+	var err error
+	if f.Players, err = parseUserIDList(c, "players"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return f, false
+	}
+	if f.ExcludePlayers, err = parseUserIDList(c, "exclude_players"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return f, false
+	}
+	for _, raw := range queryList(c, "decks") {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Некорректный decks: %q", raw)})
+			return f, false
+		}
+		f.Decks = append(f.Decks, id)
+	}
+	if raw := c.Query("team_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "team_size должен быть положительным числом"})
+			return f, false
+		}
+		f.TeamSize = n
+	}
+	if raw := c.Query("include_technical"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "include_technical должен быть true|false"})
+			return f, false
+		}
+		f.IncludeTechnical = v
+	}
+	if raw := c.Query("min_games"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_games должен быть положительным числом"})
+			return f, false
+		}
+		f.MinGames = n
+	}
+	return f, true
+}
+
+// parseStatsDay — начало дня YYYY-MM-DD в часовом поясе loc (UTC); endOfDay — последний момент этого дня.
+func parseStatsDay(raw string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", raw, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		// AddDate, а не 24 часа: в дни перехода на летнее время сутки короче или длиннее.
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t.UTC(), nil
+}
+
+// parseStatsDateRange разбирает from/to (YYYY-MM-DD в часовом поясе loc, to — включительно до конца дня)
+// или season_id — даты сезона (с from/to не сочетается). При ошибке пишет ответ и возвращает ok == false.
+func parseStatsDateRange(c *gin.Context, loc *time.Location) (fromDate, toDate *time.Time, ok bool) {
+	if raw := strings.TrimSpace(c.Query("season_id")); raw != "" {
+		if c.Query("from") != "" || c.Query("to") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "season_id нельзя сочетать с from/to"})
+			return nil, nil, false
+		}
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный season_id"})
+			return nil, nil, false
+		}
+		var season models.Season
+		if err := database.GetDB().First(&season, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Сезон не найден"})
+			return nil, nil, false
+		}
+		from, to, err := seasonDateRange(season, loc)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Некорректные даты сезона"})
+			return nil, nil, false
+		}
+		return &from, &to, true
+	}
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		t, err := parseStatsDay(raw, loc, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный from, формат YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		fromDate = &t
+	}
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		t, err := parseStatsDay(raw, loc, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный to, формат YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		toDate = &t
+	}
+	if fromDate != nil && toDate != nil && fromDate.After(*toDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from должен быть <= to"})
+		return nil, nil, false
+	}
+	return fromDate, toDate, true
+}
+
+// buildCompletedGamesWhereClause — условие на завершённые игры (alias — псевдоним games) с учётом фильтров.
+// min_games сюда не входит: это порог для строк агрегата, он применяется в самих эндпоинтах.
+func buildCompletedGamesWhereClause(alias string, f statsFilter) (string, []interface{}) {
+	where := fmt.Sprintf("%s.end_time IS NOT NULL AND %s.winning_team IS NOT NULL", alias, alias)
+	args := make([]interface{}, 0, 8)
+	if f.From != nil {
+		where += fmt.Sprintf(" AND %s.start_time >= ?", alias)
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		where += fmt.Sprintf(" AND %s.start_time <= ?", alias)
+		args = append(args, *f.To)
+	}
+	if !f.IncludeTechnical {
+		where += fmt.Sprintf(" AND NOT %s.is_technical_defeat", alias)
+	}
+	if len(f.Players) > 0 {
+		where += fmt.Sprintf(" AND (SELECT COUNT(DISTINCT fp.user_id) FROM game_players fp WHERE fp.game_id = %s.id AND fp.user_id IN ?) = ?", alias)
+		args = append(args, f.Players, len(uniqueUints(f.Players)))
+	}
+	if len(f.ExcludePlayers) > 0 {
+		where += fmt.Sprintf(" AND NOT EXISTS (SELECT 1 FROM game_players fp WHERE fp.game_id = %s.id AND fp.user_id IN ?)", alias)
+		args = append(args, f.ExcludePlayers)
+	}
+	if len(f.Decks) > 0 {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM game_players fp WHERE fp.game_id = %s.id AND fp.deck_id IN ?)", alias)
+		args = append(args, f.Decks)
+	}
+	if f.TeamSize > 0 {
+		where += fmt.Sprintf(" AND (SELECT COUNT(*) FROM game_players fp WHERE fp.game_id = %s.id) = ?", alias)
+		args = append(args, f.TeamSize*2)
+	}
+	return where, args
+}
+
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// statsCacheKey — канонический ключ кэша: путь и отсортированные параметры запроса,
+// чтобы ?to=..&from=.. и ?from=..&to=.. попадали в одну запись.
+func statsCacheKey(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	canonical := make(url.Values, len(query))
+	for key, values := range query {
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		canonical[key] = sorted
+	}
+	if len(canonical) == 0 {
+		return c.Request.URL.Path
+	}
+	return c.Request.URL.Path + "?" + canonical.Encode()
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// gameLengthExtremesLimit — сколько самых быстрых и самых долгих партий отдаётся в ответе.
+const gameLengthExtremesLimit = 5
+
+// gameLengthRow — завершённая партия с длительностью без пауз и числом ходов.
+type gameLengthRow struct {
+	GameID       uint      `gorm:"column:game_id"`
+	StartTime    time.Time `gorm:"column:start_time"`
+	EndTime      time.Time `gorm:"column:end_time"`
+	DurationSec  int       `gorm:"column:duration_sec"`
+	TurnsCount   int       `gorm:"column:turns_count"`
+	PlayersCount int       `gorm:"column:players_count"`
+}
+
+// loadGameLengths — длительности завершённых партий (end_time - start_time - total_pause_duration_seconds) по условию where на games g.
+func loadGameLengths(db *gorm.DB, where string, args []interface{}) ([]gameLengthRow, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			g.id AS game_id,
+			g.start_time,
+			g.end_time,
+			GREATEST(EXTRACT(EPOCH FROM (g.end_time - g.start_time))::int - g.total_pause_duration_seconds, 0) AS duration_sec,
+			(SELECT COUNT(*) FROM game_turns gt WHERE gt.game_id = g.id) AS turns_count,
+			(SELECT COUNT(*) FROM game_players gp WHERE gp.game_id = g.id) AS players_count
+		FROM games g
+		WHERE %s
+		ORDER BY g.start_time ASC, g.id ASC
+	`, where)
+	var rows []gameLengthRow
+	err := db.Raw(query, args...).Scan(&rows).Error
+	return rows, err
+}
+
+// gameLengthSummary — сводка длительности и числа ходов по набору партий.
+func gameLengthSummary(games []gameLengthRow) models.GameLengthSummary {
+	s := models.GameLengthSummary{GamesCount: len(games)}
+	if len(games) == 0 {
+		return s
+	}
+	durations := make([]int, 0, len(games))
+	turns := make([]int, 0, len(games))
+	totalDuration, totalTurns := 0, 0
+	for _, g := range games {
+		durations = append(durations, g.DurationSec)
+		turns = append(turns, g.TurnsCount)
+		totalDuration += g.DurationSec
+		totalTurns += g.TurnsCount
+	}
+	sort.Ints(durations)
+	sort.Ints(turns)
+	s.AvgDurationSec = float64(totalDuration) / float64(len(games))
+	s.MedianDurationSec = percentile(durations, 0.5)
+	s.P25DurationSec = percentile(durations, 0.25)
+	s.P75DurationSec = percentile(durations, 0.75)
+	s.P90DurationSec = percentile(durations, 0.9)
+	s.MinDurationSec = durations[0]
+	s.MaxDurationSec = durations[len(durations)-1]
+	s.AvgTurns = float64(totalTurns) / float64(len(games))
+	s.MedianTurns = percentile(turns, 0.5)
+	return s
+}
+
+// GetGameLengthStats — сколько длятся партии: длительность без пауз и число ходов в целом, по колодам,
+// матчапам, числу игроков и периодам (group_by, по умолчанию week), самые быстрые и самые долгие партии.
+// Поддерживает общие фильтры (statsFilter); min_games — порог партий для колод и матчапов.
+func GetGameLengthStats(c *gin.Context) {
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	groupBy := c.DefaultQuery("group_by", "week")
+	if groupBy != "day" && groupBy != "week" && groupBy != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by должен быть day|week|month"})
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	games, err := loadGameLengths(db, whereClause, whereArgs)
+	if err != nil {
+		log.Printf("GetGameLengthStats: games: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить длительность игр"})
+		return
+	}
+
+	type seatRow struct {
+		GameID     uint   `gorm:"column:game_id"`
+		DeckID     int    `gorm:"column:deck_id"`
+		DeckName   string `gorm:"column:deck_name"`
+		PlayerTeam int    `gorm:"column:player_team"`
+	}
+	seatsQuery := fmt.Sprintf(`
+		WITH %s
+		SELECT game_id, deck_id, deck_name, player_team
+		FROM players_with_team
+		ORDER BY game_id, player_index
+	`, playersWithTeamCTE(whereClause))
+	var seats []seatRow
+	if err := db.Raw(seatsQuery, whereArgs...).Scan(&seats).Error; err != nil {
+		log.Printf("GetGameLengthStats: players: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить участников игр"})
+		return
+	}
+	seatsByGame := make(map[uint][]seatRow, len(games))
+	for _, s := range seats {
+		seatsByGame[s.GameID] = append(seatsByGame[s.GameID], s)
+	}
+
+	type deckGroup struct {
+		name  string
+		games []gameLengthRow
+	}
+	type matchupGroup struct {
+		deck1Name, deck2Name string
+		games                []gameLengthRow
+	}
+	byDeck := make(map[int]*deckGroup)
+	byMatchup := make(map[deckPairKey]*matchupGroup)
+	byPlayerCount := make(map[int][]gameLengthRow)
+	byPeriod := make(map[string][]gameLengthRow)
+	for _, g := range games {
+		byPlayerCount[g.PlayersCount] = append(byPlayerCount[g.PlayersCount], g)
+		period := periodKey(g.StartTime, groupBy, filter.Location)
+		byPeriod[period] = append(byPeriod[period], g)
+
+		// Партия учитывается у колоды и матчапа один раз, даже если колода встречается в ней несколько раз.
+		gameSeats := seatsByGame[g.GameID]
+		seenDecks := make(map[int]bool, len(gameSeats))
+		seenMatchups := make(map[deckPairKey]bool)
+		for _, s := range gameSeats {
+			if !seenDecks[s.DeckID] {
+				seenDecks[s.DeckID] = true
+				if byDeck[s.DeckID] == nil {
+					byDeck[s.DeckID] = &deckGroup{}
+				}
+				byDeck[s.DeckID].name = s.DeckName
+				byDeck[s.DeckID].games = append(byDeck[s.DeckID].games, g)
+			}
+			if s.PlayerTeam != 1 {
+				continue
+			}
+			for _, o := range gameSeats {
+				if o.PlayerTeam != 2 {
+					continue
+				}
+				d1, d2 := s, o
+				if d1.DeckID > d2.DeckID {
+					d1, d2 = d2, d1
+				}
+				key := deckPairKey{d1.DeckID, d2.DeckID}
+				if seenMatchups[key] {
+					continue
+				}
+				seenMatchups[key] = true
+				if byMatchup[key] == nil {
+					byMatchup[key] = &matchupGroup{}
+				}
+				byMatchup[key].deck1Name, byMatchup[key].deck2Name = d1.DeckName, d2.DeckName
+				byMatchup[key].games = append(byMatchup[key].games, g)
+			}
+		}
+	}
+
+	minGames := filter.minGamesOr(1)
+	resp := models.GameLengthResponse{
+		GroupBy:       groupBy,
+		Timezone:      filter.Timezone,
+		Overall:       gameLengthSummary(games),
+		ByDeck:        make([]models.DeckGameLength, 0, len(byDeck)),
+		ByMatchup:     make([]models.MatchupGameLength, 0, len(byMatchup)),
+		ByPlayerCount: make([]models.PlayerCountGameLength, 0, len(byPlayerCount)),
+		ByPeriod:      make([]models.PeriodGameLength, 0, len(byPeriod)),
+	}
+	for id, d := range byDeck {
+		if len(d.games) < minGames {
+			continue
+		}
+		resp.ByDeck = append(resp.ByDeck, models.DeckGameLength{DeckID: id, DeckName: d.name, GameLengthSummary: gameLengthSummary(d.games)})
+	}
+	sort.Slice(resp.ByDeck, func(i, j int) bool { return resp.ByDeck[i].DeckName < resp.ByDeck[j].DeckName })
+	for key, m := range byMatchup {
+		if len(m.games) < minGames {
+			continue
+		}
+		resp.ByMatchup = append(resp.ByMatchup, models.MatchupGameLength{
+			Deck1ID:           key.deck1ID,
+			Deck1Name:         m.deck1Name,
+			Deck2ID:           key.deck2ID,
+			Deck2Name:         m.deck2Name,
+			GameLengthSummary: gameLengthSummary(m.games),
+		})
+	}
+	sort.Slice(resp.ByMatchup, func(i, j int) bool {
+		if resp.ByMatchup[i].Deck1Name != resp.ByMatchup[j].Deck1Name {
+			return resp.ByMatchup[i].Deck1Name < resp.ByMatchup[j].Deck1Name
+		}
+		return resp.ByMatchup[i].Deck2Name < resp.ByMatchup[j].Deck2Name
+	})
+	for count, rows := range byPlayerCount {
+		resp.ByPlayerCount = append(resp.ByPlayerCount, models.PlayerCountGameLength{PlayersCount: count, GameLengthSummary: gameLengthSummary(rows)})
+	}
+	sort.Slice(resp.ByPlayerCount, func(i, j int) bool { return resp.ByPlayerCount[i].PlayersCount < resp.ByPlayerCount[j].PlayersCount })
+	for period, rows := range byPeriod {
+		resp.ByPeriod = append(resp.ByPeriod, models.PeriodGameLength{Period: period, GameLengthSummary: gameLengthSummary(rows)})
+	}
+	sort.Slice(resp.ByPeriod, func(i, j int) bool { return resp.ByPeriod[i].Period < resp.ByPeriod[j].Period })
+
+	loc := filter.Location
+	entry := func(g gameLengthRow) models.GameLengthEntry {
+		deckNames := make([]string, 0, len(seatsByGame[g.GameID]))
+		for _, s := range seatsByGame[g.GameID] {
+			deckNames = append(deckNames, s.DeckName)
+		}
+		return models.GameLengthEntry{
+			GameID:       g.GameID,
+			StartTime:    inLocation(g.StartTime, loc),
+			EndTime:      inLocation(g.EndTime, loc),
+			DurationSec:  g.DurationSec,
+			TurnsCount:   g.TurnsCount,
+			PlayersCount: g.PlayersCount,
+			DeckNames:    deckNames,
+		}
+	}
+	sorted := append([]gameLengthRow(nil), games...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].DurationSec < sorted[j].DurationSec })
+	limit := gameLengthExtremesLimit
+	if limit > len(sorted) {
+		limit = len(sorted)
+	}
+	resp.Fastest = make([]models.GameLengthEntry, 0, limit)
+	resp.Slowest = make([]models.GameLengthEntry, 0, limit)
+	for i := 0; i < limit; i++ {
+		resp.Fastest = append(resp.Fastest, entry(sorted[i]))
+		resp.Slowest = append(resp.Slowest, entry(sorted[len(sorted)-1-i]))
+	}
+
+	writeStatsCacheJSON(c, resp)
+}
+
+// GetActiveGameEstimate — прогноз оставшегося времени активной партии по завершённым партиям с тем же числом игроков.
+// Не кэшируется: прошедшее время меняется с каждым запросом.
+func GetActiveGameEstimate(c *gin.Context) {
+	db := database.GetDB()
+	var game models.Game
+	if err := db.Where("end_time IS NULL").Scopes(withGameAssociations).First(&game).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Нет активной игры"})
+		return
+	}
+
+	now := time.Now().UTC()
+	pauseSec := gamePauseSeconds(game)
+	if game.IsPaused && game.PauseStartedAt != nil && now.After(*game.PauseStartedAt) {
+		pauseSec += int(now.Sub(*game.PauseStartedAt).Seconds())
+	}
+	elapsed := int(now.Sub(game.StartTime).Seconds()) - pauseSec
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	resp := models.ActiveGameEstimate{
+		GameID:       game.ID,
+		PlayersCount: len(game.Players),
+		ElapsedSec:   elapsed,
+		TurnsCount:   len(game.Turns),
+	}
+
+	games, err := loadGameLengths(db,
+		"g.end_time IS NOT NULL AND g.winning_team IS NOT NULL AND (SELECT COUNT(*) FROM game_players fp WHERE fp.game_id = g.id) = ?",
+		[]interface{}{len(game.Players)})
+	if err != nil {
+		log.Printf("GetActiveGameEstimate: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить длительность игр"})
+		return
+	}
+	// Условная медиана: партии, уже пережившие прошедшее время, лучше описывают, сколько осталось.
+	longer := make([]gameLengthRow, 0, len(games))
+	for _, g := range games {
+		if g.DurationSec > elapsed {
+			longer = append(longer, g)
+		}
+	}
+	resp.SampleSize = len(longer)
+	if len(longer) > 0 {
+		summary := gameLengthSummary(longer)
+		total := summary.MedianDurationSec
+		remaining := total - float64(elapsed)
+		turns := summary.MedianTurns
+		resp.ExpectedTotalSec = &total
+		resp.ExpectedRemainingSec = &remaining
+		resp.ExpectedTotalTurns = &turns
+	}
+	c.JSON(http.StatusOK, resp)
+}
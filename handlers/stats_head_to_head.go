@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headToHeadDefaultLimit = 10
+	headToHeadMaxLimit     = 100
+)
+
+// parseStatsUserID — обязательный положительный ID пользователя из query; при ошибке пишет 400.
+func parseStatsUserID(c *gin.Context, key string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Query(key), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Некорректный %s", key)})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// headToHeadDecks — колоды игрока в партиях против соперника, по убыванию числа игр.
+func headToHeadDecks(byDeck map[int]*models.HeadToHeadDeck) []models.HeadToHeadDeck {
+	out := make([]models.HeadToHeadDeck, 0, len(byDeck))
+	for _, d := range byDeck {
+		if d.GamesCount > 0 {
+			d.WinRate = float64(d.WinsCount) / float64(d.GamesCount) * 100
+		}
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].GamesCount != out[j].GamesCount {
+			return out[i].GamesCount > out[j].GamesCount
+		}
+		return out[i].DeckName < out[j].DeckName
+	})
+	return out
+}
+
+// GetHeadToHead — статистика двух игроков друг против друга и в одной команде.
+// Команды выводятся так же, как в GetPlayerStats (playersWithTeamCTE); поддерживаются общие фильтры (statsFilter)
+// и limit последних встреч.
+func GetHeadToHead(c *gin.Context) {
+	userA, ok := parseStatsUserID(c, "user_a")
+	if !ok {
+		return
+	}
+	userB, ok := parseStatsUserID(c, "user_b")
+	if !ok {
+		return
+	}
+	if userA == userB {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_a и user_b должны различаться"})
+		return
+	}
+	limit := headToHeadDefaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > headToHeadMaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit должен быть от 0 до %d", headToHeadMaxLimit)})
+			return
+		}
+		limit = n
+	}
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	fromDate, toDate := filter.From, filter.To
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	var users []models.User
+	if err := db.Where("id IN ?", []uint{userA, userB}).Find(&users).Error; err != nil || len(users) != 2 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+	names := make(map[uint]string, 2)
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	type meetingRow struct {
+		GameID      uint      `gorm:"column:game_id"`
+		StartTime   time.Time `gorm:"column:start_time"`
+		EndTime     time.Time `gorm:"column:end_time"`
+		WinningTeam int       `gorm:"column:winning_team"`
+		TeamA       int       `gorm:"column:team_a"`
+		TeamB       int       `gorm:"column:team_b"`
+		DeckAID     int       `gorm:"column:deck_a_id"`
+		DeckAName   string    `gorm:"column:deck_a_name"`
+		DeckBID     int       `gorm:"column:deck_b_id"`
+		DeckBName   string    `gorm:"column:deck_b_name"`
+	}
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT
+			a.game_id,
+			a.start_time,
+			a.end_time,
+			a.winning_team,
+			a.player_team AS team_a,
+			b.player_team AS team_b,
+			a.deck_id AS deck_a_id,
+			a.deck_name AS deck_a_name,
+			b.deck_id AS deck_b_id,
+			b.deck_name AS deck_b_name
+		FROM players_with_team a
+		JOIN players_with_team b ON b.game_id = a.game_id
+		WHERE a.user_id = ? AND b.user_id = ?
+		ORDER BY a.end_time DESC, a.game_id DESC
+	`, playersWithTeamCTE(whereClause))
+	args := append(append([]interface{}{}, whereArgs...), userA, userB)
+	var rows []meetingRow
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		log.Printf("GetHeadToHead: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику встреч"})
+		return
+	}
+
+	loc := filter.Location
+	resp := models.HeadToHeadResponse{
+		UserA:          models.HeadToHeadPlayer{UserID: userA, PlayerName: names[userA]},
+		UserB:          models.HeadToHeadPlayer{UserID: userB, PlayerName: names[userB]},
+		GamesTogether:  len(rows),
+		RecentMeetings: make([]models.HeadToHeadMeeting, 0, limit),
+	}
+	decksA := make(map[int]*models.HeadToHeadDeck)
+	decksB := make(map[int]*models.HeadToHeadDeck)
+	countDeck := func(byDeck map[int]*models.HeadToHeadDeck, id int, name string, won bool) {
+		d := byDeck[id]
+		if d == nil {
+			d = &models.HeadToHeadDeck{DeckID: id, DeckName: name}
+			byDeck[id] = d
+		}
+		d.GamesCount++
+		if won {
+			d.WinsCount++
+		}
+	}
+	// Строки идут от новых к старым: серия — подряд идущие победы одного игрока в последних очных партиях.
+	var streakWinner uint
+	streakOpen := true
+	for _, r := range rows {
+		wonA := r.TeamA == r.WinningTeam
+		wonB := r.TeamB == r.WinningTeam
+		relation := "opponents"
+		if r.TeamA == r.TeamB {
+			relation = "teammates"
+			resp.Teammates.GamesCount++
+			if wonA {
+				resp.Teammates.WinsCount++
+			}
+		} else {
+			resp.Opponents.GamesCount++
+			winner := userB
+			if wonA {
+				resp.Opponents.UserAWins++
+				winner = userA
+			} else {
+				resp.Opponents.UserBWins++
+			}
+			countDeck(decksA, r.DeckAID, r.DeckAName, wonA)
+			countDeck(decksB, r.DeckBID, r.DeckBName, wonB)
+			if streakOpen {
+				switch {
+				case resp.Opponents.CurrentStreak == nil:
+					streakWinner = winner
+					resp.Opponents.CurrentStreak = &models.HeadToHeadStreak{UserID: winner, Length: 1}
+				case winner == streakWinner:
+					resp.Opponents.CurrentStreak.Length++
+				default:
+					streakOpen = false
+				}
+			}
+		}
+		if len(resp.RecentMeetings) < limit {
+			resp.RecentMeetings = append(resp.RecentMeetings, models.HeadToHeadMeeting{
+				GameID:        r.GameID,
+				StartTime:     inLocation(r.StartTime, loc),
+				EndTime:       inLocation(r.EndTime, loc),
+				Relation:      relation,
+				UserATeam:     r.TeamA,
+				UserBTeam:     r.TeamB,
+				WinningTeam:   r.WinningTeam,
+				UserAWon:      wonA,
+				UserBWon:      wonB,
+				UserADeckID:   r.DeckAID,
+				UserADeckName: r.DeckAName,
+				UserBDeckID:   r.DeckBID,
+				UserBDeckName: r.DeckBName,
+			})
+		}
+	}
+	if n := resp.Opponents.GamesCount; n > 0 {
+		resp.Opponents.UserAWinRate = float64(resp.Opponents.UserAWins) / float64(n) * 100
+		resp.Opponents.UserBWinRate = float64(resp.Opponents.UserBWins) / float64(n) * 100
+	}
+	if n := resp.Teammates.GamesCount; n > 0 {
+		resp.Teammates.WinRate = float64(resp.Teammates.WinsCount) / float64(n) * 100
+	}
+	resp.Opponents.UserADecks = headToHeadDecks(decksA)
+	resp.Opponents.UserBDecks = headToHeadDecks(decksB)
+	if fromDate != nil {
+		resp.FromDate = fromDate.In(filter.Location).Format("2006-01-02")
+	}
+	if toDate != nil {
+		resp.ToDate = toDate.In(filter.Location).Format("2006-01-02")
+	}
+	writeStatsCacheJSON(c, resp)
+}
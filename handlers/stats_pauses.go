@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPauseStats — частота пауз и потерянное на паузах время по завершённым играм.
+// Время пауз считается по интервалам game_pauses; для старых партий без интервалов — по накопленному счётчику.
+// Поддерживает общие фильтры (statsFilter).
+func GetPauseStats(c *gin.Context) {
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+
+	type gamePauseRow struct {
+		GameID      uint       `gorm:"column:game_id"`
+		StartTime   time.Time  `gorm:"column:start_time"`
+		EndTime     *time.Time `gorm:"column:end_time"`
+		PausesCount int        `gorm:"column:pauses_count"`
+		PauseSec    int        `gorm:"column:pause_sec"`
+	}
+	gamesQuery := fmt.Sprintf(`
+		SELECT
+			g.id AS game_id,
+			g.start_time,
+			g.end_time,
+			COUNT(p.id) AS pauses_count,
+			CASE
+				WHEN COUNT(p.id) = 0 THEN g.total_pause_duration_seconds
+				ELSE COALESCE(SUM(EXTRACT(EPOCH FROM (p.ended_at - p.started_at)))::int, 0)
+			END AS pause_sec
+		FROM games g
+		LEFT JOIN game_pauses p ON p.game_id = g.id AND p.ended_at IS NOT NULL
+		WHERE %s
+		GROUP BY g.id
+		ORDER BY g.start_time DESC
+	`, whereClause)
+	var gameRows []gamePauseRow
+	if err := db.Raw(gamesQuery, whereArgs...).Scan(&gameRows).Error; err != nil {
+		log.Printf("GetPauseStats: games: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику пауз"})
+		return
+	}
+
+	type reasonRow struct {
+		Reason        string `gorm:"column:reason"`
+		PausesCount   int    `gorm:"column:pauses_count"`
+		TotalPauseSec int    `gorm:"column:total_pause_sec"`
+	}
+	reasonsQuery := fmt.Sprintf(`
+		SELECT
+			p.reason,
+			COUNT(*) AS pauses_count,
+			COALESCE(SUM(EXTRACT(EPOCH FROM (p.ended_at - p.started_at)))::int, 0) AS total_pause_sec
+		FROM game_pauses p
+		JOIN games g ON g.id = p.game_id
+		WHERE %s AND p.ended_at IS NOT NULL
+		GROUP BY p.reason
+		ORDER BY pauses_count DESC, p.reason ASC
+	`, whereClause)
+	var reasonRows []reasonRow
+	if err := db.Raw(reasonsQuery, whereArgs...).Scan(&reasonRows).Error; err != nil {
+		log.Printf("GetPauseStats: reasons: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику пауз"})
+		return
+	}
+
+	resp := models.PauseStatsResponse{
+		TotalGames: len(gameRows),
+		ByReason:   make([]models.PauseReasonStat, 0, len(reasonRows)),
+		Games:      make([]models.GamePauseStat, 0, len(gameRows)),
+	}
+	for _, r := range gameRows {
+		if r.PausesCount > 0 || r.PauseSec > 0 {
+			resp.GamesWithPauses++
+		}
+		resp.TotalPauses += r.PausesCount
+		resp.TotalPauseSec += r.PauseSec
+		resp.Games = append(resp.Games, models.GamePauseStat{
+			GameID:      r.GameID,
+			StartTime:   r.StartTime,
+			EndTime:     r.EndTime,
+			PausesCount: r.PausesCount,
+			PauseSec:    r.PauseSec,
+		})
+	}
+	if resp.TotalGames > 0 {
+		resp.PausesPerGame = float64(resp.TotalPauses) / float64(resp.TotalGames)
+		resp.AvgPauseSecPerGame = float64(resp.TotalPauseSec) / float64(resp.TotalGames)
+	}
+	intervalSec := 0
+	for _, r := range reasonRows {
+		intervalSec += r.TotalPauseSec
+		resp.ByReason = append(resp.ByReason, models.PauseReasonStat{
+			Reason:        r.Reason,
+			PausesCount:   r.PausesCount,
+			TotalPauseSec: r.TotalPauseSec,
+		})
+	}
+	if resp.TotalPauses > 0 {
+		resp.AvgPauseDurationSec = float64(intervalSec) / float64(resp.TotalPauses)
+	}
+	writeStatsCacheJSON(c, resp)
+}
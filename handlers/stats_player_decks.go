@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlayerDeckStats — полная матрица «игрок × колода»: игры, победы, процент, среднее время хода и последняя игра.
+// user_id — только колоды одного игрока (экран «мои колоды»). Поддерживает общие фильтры (statsFilter).
+func GetPlayerDeckStats(c *gin.Context) {
+	var userID *uint
+	if c.Query("user_id") != "" {
+		id, ok := parseStatsUserID(c, "user_id")
+		if !ok {
+			return
+		}
+		userID = &id
+	}
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	args := append([]interface{}{}, whereArgs...)
+	userFilter := ""
+	if userID != nil {
+		userFilter = "WHERE user_id = ?"
+		args = append(args, *userID)
+	}
+	args = append(args, filter.minGamesOr(1))
+
+	type playerDeckRow struct {
+		UserID             uint      `gorm:"column:user_id"`
+		PlayerName         string    `gorm:"column:player_name"`
+		DeckID             int       `gorm:"column:deck_id"`
+		DeckName           string    `gorm:"column:deck_name"`
+		GamesCount         int       `gorm:"column:games_count"`
+		WinsCount          int       `gorm:"column:wins_count"`
+		AvgTurnDurationSec int       `gorm:"column:avg_turn_duration_sec"`
+		LastPlayedAt       time.Time `gorm:"column:last_played_at"`
+	}
+	query := fmt.Sprintf(`
+		WITH %s,
+		player_decks AS (
+			SELECT
+				user_id,
+				deck_id,
+				MAX(player_name) AS player_name,
+				MAX(deck_name) AS deck_name,
+				COUNT(*) AS games_count,
+				SUM(CASE WHEN player_team = winning_team THEN 1 ELSE 0 END) AS wins_count,
+				MAX(end_time) AS last_played_at
+			FROM players_with_team
+			%s
+			GROUP BY user_id, deck_id
+			HAVING COUNT(*) >= ?
+		),
+		player_deck_turns AS (
+			SELECT
+				pwt.user_id,
+				pwt.deck_id,
+				COALESCE(AVG(gt.duration)::int, 0) AS avg_turn_duration_sec
+			FROM players_with_team pwt
+			JOIN game_turns gt ON gt.game_id = pwt.game_id
+			WHERE gt.team_number = pwt.player_team
+			GROUP BY pwt.user_id, pwt.deck_id
+		)
+		SELECT
+			pd.*,
+			COALESCE(pdt.avg_turn_duration_sec, 0) AS avg_turn_duration_sec
+		FROM player_decks pd
+		LEFT JOIN player_deck_turns pdt ON pdt.user_id = pd.user_id AND pdt.deck_id = pd.deck_id
+		ORDER BY pd.player_name ASC, pd.games_count DESC, pd.deck_name ASC
+	`, playersWithTeamCTE(whereClause), userFilter)
+	if useStatsAggregates(db, filter) {
+		args = args[:0]
+		userFilter = ""
+		if userID != nil {
+			userFilter = "spd.user_id = ? AND "
+			args = append(args, *userID)
+		}
+		args = append(args, filter.minGamesOr(1))
+		query = fmt.Sprintf(`
+			SELECT
+				spd.user_id,
+				u.name AS player_name,
+				spd.deck_id,
+				spd.deck_name,
+				spd.games_count,
+				spd.wins_count,
+				CASE WHEN spd.turns_count > 0 THEN ROUND(spd.turns_duration_sec::numeric / spd.turns_count)::int ELSE 0 END AS avg_turn_duration_sec,
+				spd.last_played_at
+			FROM stats_player_decks spd
+			JOIN users u ON u.id = spd.user_id
+			WHERE %sspd.games_count >= ?
+			ORDER BY u.name ASC, spd.games_count DESC, spd.deck_name ASC
+		`, userFilter)
+	}
+	var rows []playerDeckRow
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		log.Printf("GetPlayerDeckStats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику игроков по колодам"})
+		return
+	}
+
+	loc := filter.Location
+	out := make([]models.PlayerDeckStats, 0, len(rows))
+	for _, r := range rows {
+		winRate := 0.0
+		if r.GamesCount > 0 {
+			winRate = float64(r.WinsCount) / float64(r.GamesCount) * 100
+		}
+		out = append(out, models.PlayerDeckStats{
+			UserID:             r.UserID,
+			PlayerName:         r.PlayerName,
+			DeckID:             r.DeckID,
+			DeckName:           r.DeckName,
+			GamesCount:         r.GamesCount,
+			WinsCount:          r.WinsCount,
+			WinRate:            winRate,
+			AvgTurnDurationSec: r.AvgTurnDurationSec,
+			LastPlayedAt:       inLocation(r.LastPlayedAt, loc),
+		})
+	}
+	writeStatsCacheJSON(c, out)
+}
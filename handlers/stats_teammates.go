@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTeammateStats — пары игроков, игравших в одной команде: совместные игры, победы
+// и разница процента побед пары с личным процентом каждого игрока.
+// sort=best|worst — порядок по проценту побед пары; user_id — только пары с этим игроком; min_games — порог игр пары.
+func GetTeammateStats(c *gin.Context) {
+	sortOrder := c.DefaultQuery("sort", "best")
+	if sortOrder != "best" && sortOrder != "worst" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort должен быть best|worst"})
+		return
+	}
+	var userID *uint
+	if c.Query("user_id") != "" {
+		id, ok := parseStatsUserID(c, "user_id")
+		if !ok {
+			return
+		}
+		userID = &id
+	}
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	minGames := filter.minGamesOr(1)
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	type pairRow struct {
+		Player1ID    uint   `gorm:"column:player1_id"`
+		Player1Name  string `gorm:"column:player1_name"`
+		Player2ID    uint   `gorm:"column:player2_id"`
+		Player2Name  string `gorm:"column:player2_name"`
+		GamesCount   int    `gorm:"column:games_count"`
+		WinsCount    int    `gorm:"column:wins_count"`
+		Player1Games int    `gorm:"column:player1_games"`
+		Player1Wins  int    `gorm:"column:player1_wins"`
+		Player2Games int    `gorm:"column:player2_games"`
+		Player2Wins  int    `gorm:"column:player2_wins"`
+	}
+	query := fmt.Sprintf(`
+		WITH %s,
+		individual AS (
+			SELECT
+				user_id,
+				COUNT(*) AS games_count,
+				SUM(CASE WHEN player_team = winning_team THEN 1 ELSE 0 END) AS wins_count
+			FROM players_with_team
+			GROUP BY user_id
+		),
+		pairs AS (
+			SELECT
+				a.user_id AS player1_id,
+				MAX(a.player_name) AS player1_name,
+				b.user_id AS player2_id,
+				MAX(b.player_name) AS player2_name,
+				COUNT(*) AS games_count,
+				SUM(CASE WHEN a.player_team = a.winning_team THEN 1 ELSE 0 END) AS wins_count
+			FROM players_with_team a
+			JOIN players_with_team b
+				ON b.game_id = a.game_id
+				AND b.player_team = a.player_team
+				AND b.user_id > a.user_id
+			GROUP BY a.user_id, b.user_id
+		)
+		SELECT
+			p.*,
+			i1.games_count AS player1_games,
+			i1.wins_count AS player1_wins,
+			i2.games_count AS player2_games,
+			i2.wins_count AS player2_wins
+		FROM pairs p
+		JOIN individual i1 ON i1.user_id = p.player1_id
+		JOIN individual i2 ON i2.user_id = p.player2_id
+		WHERE p.games_count >= ?
+	`, playersWithTeamCTE(whereClause))
+	args := append(append([]interface{}{}, whereArgs...), minGames)
+	if userID != nil {
+		query += " AND (p.player1_id = ? OR p.player2_id = ?)"
+		args = append(args, *userID, *userID)
+	}
+	var rows []pairRow
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		log.Printf("GetTeammateStats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить статистику пар"})
+		return
+	}
+
+	pairs := make([]models.TeammatePairStats, 0, len(rows))
+	for _, r := range rows {
+		// Для запроса по игроку он всегда первый в паре.
+		if userID != nil && r.Player2ID == *userID {
+			r.Player1ID, r.Player2ID = r.Player2ID, r.Player1ID
+			r.Player1Name, r.Player2Name = r.Player2Name, r.Player1Name
+			r.Player1Games, r.Player2Games = r.Player2Games, r.Player1Games
+			r.Player1Wins, r.Player2Wins = r.Player2Wins, r.Player1Wins
+		}
+		pairRate := winPercent(r.WinsCount, r.GamesCount)
+		p1Rate := winPercent(r.Player1Wins, r.Player1Games)
+		p2Rate := winPercent(r.Player2Wins, r.Player2Games)
+		pairs = append(pairs, models.TeammatePairStats{
+			Player1ID:           r.Player1ID,
+			Player1Name:         r.Player1Name,
+			Player2ID:           r.Player2ID,
+			Player2Name:         r.Player2Name,
+			GamesCount:          r.GamesCount,
+			WinsCount:           r.WinsCount,
+			WinRate:             pairRate,
+			Player1WinRate:      p1Rate,
+			Player2WinRate:      p2Rate,
+			Player1WinRateDelta: pairRate - p1Rate,
+			Player2WinRateDelta: pairRate - p2Rate,
+		})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].WinRate != pairs[j].WinRate {
+			if sortOrder == "worst" {
+				return pairs[i].WinRate < pairs[j].WinRate
+			}
+			return pairs[i].WinRate > pairs[j].WinRate
+		}
+		if pairs[i].GamesCount != pairs[j].GamesCount {
+			return pairs[i].GamesCount > pairs[j].GamesCount
+		}
+		if pairs[i].Player1Name != pairs[j].Player1Name {
+			return pairs[i].Player1Name < pairs[j].Player1Name
+		}
+		return pairs[i].Player2Name < pairs[j].Player2Name
+	})
+
+	writeStatsCacheJSON(c, models.TeammatesResponse{
+		UserID:   userID,
+		Sort:     sortOrder,
+		MinGames: minGames,
+		Pairs:    pairs,
+	})
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// turnHistogramEdges — границы корзин гистограммы длительности хода, сек; последняя корзина открыта сверху.
+var turnHistogramEdges = []int{0, 30, 60, 90, 120, 180, 300, 600}
+
+// turnSample — накопитель длительностей ходов для распределения.
+type turnSample struct {
+	durations   []int
+	overtime    int
+	overtimeSec int
+}
+
+func (s *turnSample) add(duration, overtime int) {
+	s.durations = append(s.durations, duration)
+	if overtime > 0 {
+		s.overtime++
+		s.overtimeSec += overtime
+	}
+}
+
+// percentile — перцентиль p (0..1) отсортированного среза с линейной интерполяцией (как percentile_cont).
+func percentile(sorted []int, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return float64(sorted[lo]) + (float64(sorted[hi])-float64(sorted[lo]))*(pos-float64(lo))
+}
+
+func (s *turnSample) distribution() models.TurnDistribution {
+	sorted := append([]int(nil), s.durations...)
+	sort.Ints(sorted)
+	d := models.TurnDistribution{
+		TurnsCount:       len(sorted),
+		MedianSec:        percentile(sorted, 0.5),
+		P75Sec:           percentile(sorted, 0.75),
+		P90Sec:           percentile(sorted, 0.9),
+		P95Sec:           percentile(sorted, 0.95),
+		OvertimeTurns:    s.overtime,
+		TotalOvertimeSec: s.overtimeSec,
+		Histogram:        make([]models.TurnHistogramBucket, len(turnHistogramEdges)),
+	}
+	for i, from := range turnHistogramEdges {
+		d.Histogram[i].FromSec = from
+		if i+1 < len(turnHistogramEdges) {
+			to := turnHistogramEdges[i+1]
+			d.Histogram[i].ToSec = &to
+		}
+	}
+	total := 0
+	for _, v := range sorted {
+		total += v
+		bucket := sort.Search(len(turnHistogramEdges), func(i int) bool { return turnHistogramEdges[i] > v }) - 1
+		if bucket < 0 {
+			bucket = 0
+		}
+		d.Histogram[bucket].Count++
+	}
+	if len(sorted) > 0 {
+		d.AvgSec = float64(total) / float64(len(sorted))
+		d.MaxSec = sorted[len(sorted)-1]
+		d.OvertimeRate = float64(s.overtime) / float64(len(sorted)) * 100
+	}
+	return d
+}
+
+// GetTurnStats — аналитика длительности ходов по game_turns: распределения (перцентили, гистограмма) и овертайм
+// в целом, по игрокам, колодам и командам; тренд скорости по периодам (group_by, по умолчанию week)
+// и связь скорости ходов с победой. Ход засчитывается всем игрокам своей команды.
+// Поддерживает общие фильтры (statsFilter); min_games — порог партий для игроков и колод.
+func GetTurnStats(c *gin.Context) {
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	groupBy := c.DefaultQuery("group_by", "week")
+	if groupBy != "day" && groupBy != "week" && groupBy != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by должен быть day|week|month"})
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+
+	type turnRow struct {
+		GameID      uint      `gorm:"column:game_id"`
+		TeamNumber  int       `gorm:"column:team_number"`
+		Duration    int       `gorm:"column:duration"`
+		Overtime    int       `gorm:"column:overtime"`
+		StartTime   time.Time `gorm:"column:start_time"`
+		WinningTeam int       `gorm:"column:winning_team"`
+	}
+	turnsQuery := fmt.Sprintf(`
+		SELECT
+			gt.game_id,
+			gt.team_number,
+			gt.duration,
+			gt.overtime,
+			g.start_time,
+			g.winning_team
+		FROM game_turns gt
+		JOIN games g ON g.id = gt.game_id
+		WHERE %s AND gt.team_number IN (1, 2)
+		ORDER BY g.start_time ASC, gt.id ASC
+	`, whereClause)
+	var turns []turnRow
+	if err := db.Raw(turnsQuery, whereArgs...).Scan(&turns).Error; err != nil {
+		log.Printf("GetTurnStats: turns: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить ходы"})
+		return
+	}
+
+	type seatRow struct {
+		GameID     uint   `gorm:"column:game_id"`
+		UserID     uint   `gorm:"column:user_id"`
+		PlayerName string `gorm:"column:player_name"`
+		DeckID     int    `gorm:"column:deck_id"`
+		DeckName   string `gorm:"column:deck_name"`
+		PlayerTeam int    `gorm:"column:player_team"`
+	}
+	seatsQuery := fmt.Sprintf(`
+		WITH %s
+		SELECT game_id, user_id, player_name, deck_id, deck_name, player_team
+		FROM players_with_team
+	`, playersWithTeamCTE(whereClause))
+	var seats []seatRow
+	if err := db.Raw(seatsQuery, whereArgs...).Scan(&seats).Error; err != nil {
+		log.Printf("GetTurnStats: players: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить участников игр"})
+		return
+	}
+
+	type gameTeam struct {
+		gameID uint
+		team   int
+	}
+	type playerAgg struct {
+		name   string
+		games  int
+		sample turnSample
+	}
+	type deckAgg struct {
+		name   string
+		games  int
+		sample turnSample
+	}
+	type gameAgg struct {
+		winningTeam int
+		total       [3]int
+		count       [3]int
+	}
+
+	var overall turnSample
+	var teams [3]turnSample
+	byGameTeam := make(map[gameTeam][]turnRow)
+	games := make(map[uint]*gameAgg)
+	trend := make(map[string]*turnSample)
+	for _, t := range turns {
+		overall.add(t.Duration, t.Overtime)
+		teams[t.TeamNumber].add(t.Duration, t.Overtime)
+		key := gameTeam{t.GameID, t.TeamNumber}
+		byGameTeam[key] = append(byGameTeam[key], t)
+
+		period := periodKey(t.StartTime, groupBy, filter.Location)
+		if trend[period] == nil {
+			trend[period] = &turnSample{}
+		}
+		trend[period].add(t.Duration, t.Overtime)
+
+		g := games[t.GameID]
+		if g == nil {
+			g = &gameAgg{winningTeam: t.WinningTeam}
+			games[t.GameID] = g
+		}
+		g.total[t.TeamNumber] += t.Duration
+		g.count[t.TeamNumber]++
+	}
+
+	players := make(map[uint]*playerAgg)
+	decks := make(map[int]*deckAgg)
+	for _, s := range seats {
+		p := players[s.UserID]
+		if p == nil {
+			p = &playerAgg{}
+			players[s.UserID] = p
+		}
+		p.name = s.PlayerName
+		p.games++
+		d := decks[s.DeckID]
+		if d == nil {
+			d = &deckAgg{}
+			decks[s.DeckID] = d
+		}
+		d.name = s.DeckName
+		d.games++
+		for _, t := range byGameTeam[gameTeam{s.GameID, s.PlayerTeam}] {
+			p.sample.add(t.Duration, t.Overtime)
+			d.sample.add(t.Duration, t.Overtime)
+		}
+	}
+
+	minGames := filter.minGamesOr(1)
+	resp := models.TurnStatsResponse{
+		GroupBy:  groupBy,
+		Timezone: filter.Timezone,
+		Overall:  overall.distribution(),
+		Players:  make([]models.PlayerTurnStats, 0, len(players)),
+		Decks:    make([]models.DeckTurnStats, 0, len(decks)),
+		Teams:    make([]models.TeamTurnStats, 0, 2),
+		Trend:    make([]models.TurnTrendPoint, 0, len(trend)),
+	}
+	for id, p := range players {
+		if p.games < minGames {
+			continue
+		}
+		resp.Players = append(resp.Players, models.PlayerTurnStats{
+			UserID:           id,
+			PlayerName:       p.name,
+			GamesCount:       p.games,
+			TurnDistribution: p.sample.distribution(),
+		})
+	}
+	sort.Slice(resp.Players, func(i, j int) bool { return resp.Players[i].PlayerName < resp.Players[j].PlayerName })
+	for id, d := range decks {
+		if d.games < minGames {
+			continue
+		}
+		resp.Decks = append(resp.Decks, models.DeckTurnStats{
+			DeckID:           id,
+			DeckName:         d.name,
+			GamesCount:       d.games,
+			TurnDistribution: d.sample.distribution(),
+		})
+	}
+	sort.Slice(resp.Decks, func(i, j int) bool { return resp.Decks[i].DeckName < resp.Decks[j].DeckName })
+	for team := 1; team <= 2; team++ {
+		resp.Teams = append(resp.Teams, models.TeamTurnStats{TeamNumber: team, TurnDistribution: teams[team].distribution()})
+	}
+	for period, s := range trend {
+		d := s.distribution()
+		resp.Trend = append(resp.Trend, models.TurnTrendPoint{
+			Period:       period,
+			TurnsCount:   d.TurnsCount,
+			AvgSec:       d.AvgSec,
+			MedianSec:    d.MedianSec,
+			OvertimeRate: d.OvertimeRate,
+		})
+	}
+	sort.Slice(resp.Trend, func(i, j int) bool { return resp.Trend[i].Period < resp.Trend[j].Period })
+
+	// Связь с победой: партии, где ходили обе команды; пары (средний ход команды, победа).
+	impact := &resp.WinImpact
+	var winnersSum, losersSum float64
+	var xs, ys []float64
+	decided := 0
+	for _, g := range games {
+		if g.count[1] == 0 || g.count[2] == 0 {
+			continue
+		}
+		impact.GamesCompared++
+		avg1 := float64(g.total[1]) / float64(g.count[1])
+		avg2 := float64(g.total[2]) / float64(g.count[2])
+		winnerAvg, loserAvg := avg1, avg2
+		if g.winningTeam == 2 {
+			winnerAvg, loserAvg = avg2, avg1
+		}
+		winnersSum += winnerAvg
+		losersSum += loserAvg
+		xs = append(xs, winnerAvg, loserAvg)
+		ys = append(ys, 1, 0)
+		if winnerAvg != loserAvg {
+			decided++
+			if winnerAvg < loserAvg {
+				impact.FasterTeamWins++
+			}
+		}
+	}
+	if impact.GamesCompared > 0 {
+		impact.WinnersAvgSec = winnersSum / float64(impact.GamesCompared)
+		impact.LosersAvgSec = losersSum / float64(impact.GamesCompared)
+		impact.Correlation = pearson(xs, ys)
+	}
+	if decided > 0 {
+		impact.FasterTeamWinRate = float64(impact.FasterTeamWins) / float64(decided) * 100
+	}
+
+	writeStatsCacheJSON(c, resp)
+}
+
+// pearson — коэффициент корреляции Пирсона; 0, если у одной из величин нет разброса.
+func pearson(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n == 0 {
+		return 0
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stampTurnTimes превращает ходы из запроса в GameTurn с временем начала и конца.
+// Уже сохранённые ходы сохраняют своё время, пока совпадают с запросом по команде, длительности и овертайму;
+// после первого расхождения (ход удалён или переставлен) сохранённое время не используется.
+// Последний новый ход получает серверное время: начало — прежний current_turn_start, конец — now.
+// Время от клиента используется, только если своего нет.
+func stampTurnTimes(gameID uint, inputs []models.GameTurnInput, existing []models.GameTurn, prevTurnStart *time.Time, now time.Time) []models.GameTurn {
+	turns := make([]models.GameTurn, len(inputs))
+	matched := true
+	for i, in := range inputs {
+		t := models.GameTurn{
+			GameID:     gameID,
+			TeamNumber: in.TeamNumber,
+			Duration:   in.Duration,
+			Overtime:   in.Overtime,
+		}
+		if matched && i < len(existing) {
+			e := existing[i]
+			matched = e.TeamNumber == in.TeamNumber && e.Duration == in.Duration && e.Overtime == in.Overtime
+			if matched {
+				t.StartedAt = e.StartedAt
+				t.EndedAt = e.EndedAt
+			}
+		}
+		if i == len(inputs)-1 && i >= len(existing) {
+			if t.StartedAt == nil && prevTurnStart != nil {
+				start := *prevTurnStart
+				t.StartedAt = &start
+			}
+			if t.EndedAt == nil {
+				end := now
+				t.EndedAt = &end
+			}
+		}
+		if t.StartedAt == nil {
+			t.StartedAt = in.StartedAt.Time()
+		}
+		if t.EndedAt == nil {
+			t.EndedAt = in.EndedAt.Time()
+		}
+		turns[i] = t
+	}
+	return turns
+}
+
+// timelineEventOrder — порядок событий с одинаковым временем.
+var timelineEventOrder = map[string]int{
+	"game_start": 0,
+	"pause":      1,
+	"turn":       2,
+	"finish":     3,
+}
+
+// buildGameTimeline собирает хронологию: начало, ходы, паузы и завершение по возрастанию времени.
+// Ходы без started_at/ended_at (старые партии) восстанавливаются подряд по длительностям и помечаются estimated.
+func buildGameTimeline(g models.Game) models.GameTimelineResponse {
+	turns := append([]models.GameTurn(nil), g.Turns...)
+	sort.SliceStable(turns, func(i, j int) bool { return turns[i].ID < turns[j].ID })
+
+	events := make([]models.TimelineEvent, 0, len(turns)+len(g.Pauses)+2)
+	events = append(events, models.TimelineEvent{Type: "game_start", At: g.StartTime})
+
+	cursor := g.StartTime
+	for i, t := range turns {
+		duration := time.Duration(t.Duration) * time.Second
+		ev := models.TimelineEvent{
+			Type:        "turn",
+			TurnNumber:  i + 1,
+			TeamNumber:  t.TeamNumber,
+			DurationSec: t.Duration,
+			OvertimeSec: t.Overtime,
+		}
+		switch {
+		case t.StartedAt != nil:
+			ev.At = *t.StartedAt
+		case t.EndedAt != nil:
+			ev.At = t.EndedAt.Add(-duration)
+			ev.Estimated = true
+		default:
+			ev.At = cursor
+			ev.Estimated = true
+		}
+		end := ev.At.Add(duration)
+		if t.EndedAt != nil {
+			end = *t.EndedAt
+		}
+		ev.EndAt = &end
+		if end.After(cursor) {
+			cursor = end
+		}
+		events = append(events, ev)
+	}
+
+	for _, p := range g.Pauses {
+		ev := models.TimelineEvent{
+			Type:   "pause",
+			At:     p.StartedAt,
+			EndAt:  p.EndedAt,
+			Reason: p.Reason,
+		}
+		if p.EndedAt != nil {
+			ev.DurationSec = int(p.EndedAt.Sub(p.StartedAt).Seconds())
+		}
+		events = append(events, ev)
+	}
+
+	if g.EndTime != nil {
+		events = append(events, models.TimelineEvent{
+			Type:        "finish",
+			At:          *g.EndTime,
+			WinningTeam: g.WinningTeam,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].At.Equal(events[j].At) {
+			return events[i].At.Before(events[j].At)
+		}
+		return timelineEventOrder[events[i].Type] < timelineEventOrder[events[j].Type]
+	})
+
+	var team1Clock, team2Clock int
+	for i := range events {
+		if events[i].Type == "turn" {
+			switch events[i].TeamNumber {
+			case 1:
+				team1Clock += events[i].DurationSec
+			case 2:
+				team2Clock += events[i].DurationSec
+			}
+		}
+		events[i].ElapsedSec = int(events[i].At.Sub(g.StartTime).Seconds())
+		events[i].Team1ClockSec = team1Clock
+		events[i].Team2ClockSec = team2Clock
+	}
+
+	resp := models.GameTimelineResponse{
+		GameID:            g.ID,
+		StartTime:         g.StartTime,
+		EndTime:           g.EndTime,
+		Team1Name:         g.Team1Name,
+		Team2Name:         g.Team2Name,
+		WinningTeam:       g.WinningTeam,
+		IsTechnicalDefeat: g.IsTechnicalDefeat,
+		TotalPauseSec:     gamePauseSeconds(g),
+		Team1ClockSec:     team1Clock,
+		Team2ClockSec:     team2Clock,
+		Events:            events,
+	}
+	if g.EndTime != nil {
+		resp.TotalDurationSec = int(g.EndTime.Sub(g.StartTime).Seconds())
+		resp.PlayDurationSec = resp.TotalDurationSec - resp.TotalPauseSec
+		if resp.PlayDurationSec < 0 {
+			resp.PlayDurationSec = 0
+		}
+	}
+	return resp
+}
+
+// GetGameTimeline — хронология партии в реальном времени: ходы, паузы и завершение с накопленным временем команд.
+func GetGameTimeline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID игры"})
+		return
+	}
+	db := database.GetDB()
+	var game models.Game
+	if err := db.Scopes(withGameAssociations).First(&game, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Игра не найдена"})
+		return
+	}
+
+	_, loc, _ := resolveConfiguredTimezone()
+	resp := buildGameTimeline(game)
+	resp.StartTime = inLocation(resp.StartTime, loc)
+	resp.EndTime = inLocationPtr(resp.EndTime, loc)
+	for i := range resp.Events {
+		resp.Events[i].At = inLocation(resp.Events[i].At, loc)
+		resp.Events[i].EndAt = inLocationPtr(resp.Events[i].EndAt, loc)
+	}
+	c.JSON(http.StatusOK, resp)
+}
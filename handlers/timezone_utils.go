@@ -29,3 +29,10 @@ func deckInLocation(d models.Deck, loc *time.Location) models.Deck {
 	d.UpdatedAt = inLocation(d.UpdatedAt, loc)
 	return d
 }
+
+func seasonInLocation(s models.Season, loc *time.Location) models.Season {
+	s.CreatedAt = inLocation(s.CreatedAt, loc)
+	s.UpdatedAt = inLocation(s.UpdatedAt, loc)
+	s.ClosedAt = inLocationPtr(s.ClosedAt, loc)
+	return s
+}
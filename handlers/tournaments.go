@@ -0,0 +1,857 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math/bits"
+	mathrand "math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// tournamentMatchPoints — очки за победу в матче швейцарской части (бай — тоже победа).
+	tournamentMatchPoints = 3
+	// tournamentMinRate — нижняя граница процентов для тай-брейков (правило 33% из турнирных правил MTG).
+	tournamentMinRate = 1.0 / 3.0
+)
+
+var (
+	errTournamentRoundIncomplete = errors.New("Текущий раунд не завершён")
+	errTournamentFinished        = errors.New("Турнир завершён")
+	errTournamentTooFewPlayers   = errors.New("Нужно хотя бы 2 игрока")
+	errTournamentMatchFinished   = errors.New("Матч уже завершён")
+	errTournamentMatchGameActive = errors.New("Партия матча ещё идёт")
+)
+
+// tournamentWinsNeeded — побед в партиях для победы в матче (best_of 1 → 1, best_of 3 → 2).
+func tournamentWinsNeeded(t models.Tournament) int {
+	return t.BestOf/2 + 1
+}
+
+type userPair struct{ a, b uint }
+
+func newUserPair(a, b uint) userPair {
+	if a > b {
+		a, b = b, a
+	}
+	return userPair{a, b}
+}
+
+// tournamentRecord — итоги участника в швейцарской части.
+type tournamentRecord struct {
+	matchWins, matchLosses, byes int
+	gameWins, gamesPlayed        int
+	opponents                    []uint
+}
+
+func (r *tournamentRecord) matchWinRate() float64 {
+	played := r.matchWins + r.matchLosses
+	if played == 0 {
+		return tournamentMinRate
+	}
+	return maxFloat(float64(r.matchWins)/float64(played), tournamentMinRate)
+}
+
+func (r *tournamentRecord) gameWinRate() float64 {
+	if r.gamesPlayed == 0 {
+		return tournamentMinRate
+	}
+	return maxFloat(float64(r.gameWins)/float64(r.gamesPlayed), tournamentMinRate)
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// tournamentRecords — итоги участников по завершённым матчам швейцарской части.
+// Бай засчитывается победой в матче и в партиях (best_of/2+1 из стольких же), но не добавляет соперника.
+func tournamentRecords(t models.Tournament) map[uint]*tournamentRecord {
+	records := make(map[uint]*tournamentRecord, len(t.Players))
+	for _, p := range t.Players {
+		records[p.UserID] = &tournamentRecord{}
+	}
+	record := func(userID uint) *tournamentRecord {
+		if records[userID] == nil {
+			records[userID] = &tournamentRecord{}
+		}
+		return records[userID]
+	}
+	need := tournamentWinsNeeded(t)
+	for _, m := range t.Matches {
+		if m.Stage != models.TournamentStageSwiss || m.WinnerID == nil {
+			continue
+		}
+		p1 := record(m.Player1ID)
+		if m.Player2ID == nil {
+			p1.matchWins++
+			p1.byes++
+			p1.gameWins += need
+			p1.gamesPlayed += need
+			continue
+		}
+		p2 := record(*m.Player2ID)
+		p1.opponents = append(p1.opponents, *m.Player2ID)
+		p2.opponents = append(p2.opponents, m.Player1ID)
+		if *m.WinnerID == m.Player1ID {
+			p1.matchWins++
+			p2.matchLosses++
+		} else {
+			p2.matchWins++
+			p1.matchLosses++
+		}
+		games := m.Player1Wins + m.Player2Wins
+		p1.gameWins += m.Player1Wins
+		p2.gameWins += m.Player2Wins
+		p1.gamesPlayed += games
+		p2.gamesPlayed += games
+	}
+	return records
+}
+
+// computeTournamentStandings — таблица швейцарской части: очки, затем OMW%, GW%, OGW% и имя.
+func computeTournamentStandings(t models.Tournament) []models.TournamentStanding {
+	records := tournamentRecords(t)
+	standings := make([]models.TournamentStanding, 0, len(t.Players))
+	for _, p := range t.Players {
+		r := records[p.UserID]
+		var omw, ogw float64
+		for _, opp := range r.opponents {
+			omw += records[opp].matchWinRate()
+			ogw += records[opp].gameWinRate()
+		}
+		if n := len(r.opponents); n > 0 {
+			omw /= float64(n)
+			ogw /= float64(n)
+		}
+		standings = append(standings, models.TournamentStanding{
+			UserID:       p.UserID,
+			PlayerName:   p.PlayerName,
+			DeckName:     p.DeckName,
+			MatchPoints:  r.matchWins * tournamentMatchPoints,
+			MatchWins:    r.matchWins,
+			MatchLosses:  r.matchLosses,
+			Byes:         r.byes,
+			MatchWinRate: r.matchWinRate() * 100,
+			OMWRate:      omw * 100,
+			GameWinRate:  r.gameWinRate() * 100,
+			OGWRate:      ogw * 100,
+		})
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		switch {
+		case a.MatchPoints != b.MatchPoints:
+			return a.MatchPoints > b.MatchPoints
+		case a.OMWRate != b.OMWRate:
+			return a.OMWRate > b.OMWRate
+		case a.GameWinRate != b.GameWinRate:
+			return a.GameWinRate > b.GameWinRate
+		case a.OGWRate != b.OGWRate:
+			return a.OGWRate > b.OGWRate
+		}
+		return a.PlayerName < b.PlayerName
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+// pairSwiss — пары сверху вниз по порядку ids без повторных встреч (перебор с возвратом).
+// allowRematch — запасной проход, когда без повторов разбить на пары нельзя.
+func pairSwiss(ids []uint, played map[userPair]bool, allowRematch bool) ([][2]uint, bool) {
+	if len(ids) == 0 {
+		return nil, true
+	}
+	first := ids[0]
+	for i := 1; i < len(ids); i++ {
+		if !allowRematch && played[newUserPair(first, ids[i])] {
+			continue
+		}
+		rest := make([]uint, 0, len(ids)-2)
+		rest = append(rest, ids[1:i]...)
+		rest = append(rest, ids[i+1:]...)
+		if pairs, ok := pairSwiss(rest, played, allowRematch); ok {
+			return append([][2]uint{{first, ids[i]}}, pairs...), true
+		}
+	}
+	return nil, false
+}
+
+// swissRoundMatches — матчи следующего швейцарского раунда: первый раунд — случайная рассадка,
+// дальше — по таблице; при нечётном числе бай получает самый низкий в таблице игрок без бая.
+func swissRoundMatches(t models.Tournament, round int) []models.TournamentMatch {
+	ids := make([]uint, 0, len(t.Players))
+	if round == 1 {
+		for _, p := range t.Players {
+			ids = append(ids, p.UserID)
+		}
+		rng := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+		rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	} else {
+		for _, s := range computeTournamentStandings(t) {
+			ids = append(ids, s.UserID)
+		}
+	}
+
+	played := make(map[userPair]bool)
+	hadBye := make(map[uint]bool)
+	for _, m := range t.Matches {
+		if m.Player2ID == nil {
+			hadBye[m.Player1ID] = true
+		} else {
+			played[newUserPair(m.Player1ID, *m.Player2ID)] = true
+		}
+	}
+
+	var matches []models.TournamentMatch
+	if len(ids)%2 == 1 {
+		byeIndex := len(ids) - 1
+		for i := len(ids) - 1; i >= 0; i-- {
+			if !hadBye[ids[i]] {
+				byeIndex = i
+				break
+			}
+		}
+		byeUser := ids[byeIndex]
+		ids = append(ids[:byeIndex:byeIndex], ids[byeIndex+1:]...)
+		matches = append(matches, models.TournamentMatch{
+			Player1ID:   byeUser,
+			Player1Wins: tournamentWinsNeeded(t),
+			WinnerID:    &byeUser,
+		})
+	}
+	pairs, ok := pairSwiss(ids, played, false)
+	if !ok {
+		pairs, _ = pairSwiss(ids, played, true)
+	}
+	paired := make([]models.TournamentMatch, 0, len(pairs)+len(matches))
+	for _, p := range pairs {
+		player2 := p[1]
+		paired = append(paired, models.TournamentMatch{Player1ID: p[0], Player2ID: &player2})
+	}
+	paired = append(paired, matches...)
+	for i := range paired {
+		paired[i].TournamentID = t.ID
+		paired[i].Round = round
+		paired[i].Stage = models.TournamentStageSwiss
+		paired[i].TableNumber = i + 1
+	}
+	return paired
+}
+
+// bracketSeedOrder — порядок посева сетки на выбывание (для 8: 1, 8, 4, 5, 2, 7, 3, 6): соседние — пары,
+// а победители соседних пар встречаются так, что первый и второй посев сходятся только в финале.
+func bracketSeedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// topCutSize — размер топа: top_cut, но не больше наибольшей степени двойки среди участников.
+func topCutSize(t models.Tournament) int {
+	if len(t.Players) < 2 {
+		return 0
+	}
+	limit := 1 << (bits.Len(uint(len(t.Players))) - 1)
+	if t.TopCut < limit {
+		return t.TopCut
+	}
+	return limit
+}
+
+func topMatch(t models.Tournament, round, table int, player1, player2 uint) models.TournamentMatch {
+	return models.TournamentMatch{
+		TournamentID: t.ID,
+		Round:        round,
+		Stage:        models.TournamentStageTop,
+		TableNumber:  table,
+		Player1ID:    player1,
+		Player2ID:    &player2,
+	}
+}
+
+// advanceTournament — следующий раунд турнира (или его завершение). Текущий раунд должен быть сыгран.
+func advanceTournament(tx *gorm.DB, t *models.Tournament) error {
+	var current []models.TournamentMatch
+	for _, m := range t.Matches {
+		if m.Round == t.CurrentRound {
+			if m.WinnerID == nil {
+				return errTournamentRoundIncomplete
+			}
+			current = append(current, m)
+		}
+	}
+
+	round := t.CurrentRound + 1
+	var matches []models.TournamentMatch
+	switch {
+	case t.Status == models.TournamentStatusRegistration:
+		if t.SwissRounds == 0 {
+			t.SwissRounds = bits.Len(uint(len(t.Players) - 1))
+		}
+		t.Status = models.TournamentStatusSwiss
+		matches = swissRoundMatches(*t, round)
+	case t.Status == models.TournamentStatusSwiss && t.CurrentRound < t.SwissRounds:
+		matches = swissRoundMatches(*t, round)
+	case t.Status == models.TournamentStatusSwiss && topCutSize(*t) >= 2:
+		t.Status = models.TournamentStatusTop
+		standings := computeTournamentStandings(*t)
+		order := bracketSeedOrder(topCutSize(*t))
+		for i := 0; i+1 < len(order); i += 2 {
+			matches = append(matches, topMatch(*t, round, i/2+1, standings[order[i]-1].UserID, standings[order[i+1]-1].UserID))
+		}
+	case t.Status == models.TournamentStatusTop && len(current) > 1:
+		sort.Slice(current, func(i, j int) bool { return current[i].TableNumber < current[j].TableNumber })
+		for i := 0; i+1 < len(current); i += 2 {
+			matches = append(matches, topMatch(*t, round, i/2+1, *current[i].WinnerID, *current[i+1].WinnerID))
+		}
+	default:
+		// Швейцарка без топа — чемпион первый в таблице; в топе — победитель финала.
+		t.Status = models.TournamentStatusFinished
+		if len(current) == 1 && current[0].Stage == models.TournamentStageTop {
+			t.ChampionUserID = current[0].WinnerID
+		} else if standings := computeTournamentStandings(*t); len(standings) > 0 {
+			champion := standings[0].UserID
+			t.ChampionUserID = &champion
+		}
+		return tx.Model(t).Select("status", "champion_user_id", "swiss_rounds").Updates(t).Error
+	}
+
+	if err := tx.Create(&matches).Error; err != nil {
+		return err
+	}
+	t.CurrentRound = round
+	t.Matches = append(t.Matches, matches...)
+	return tx.Model(t).Select("status", "current_round", "swiss_rounds").Updates(t).Error
+}
+
+// recordTournamentMatchGame — победа в партии матча; матч завершается, когда у кого-то best_of/2+1 побед.
+func recordTournamentMatchGame(t models.Tournament, m *models.TournamentMatch, player1Won bool) {
+	if player1Won {
+		m.Player1Wins++
+	} else {
+		m.Player2Wins++
+	}
+	need := tournamentWinsNeeded(t)
+	if m.Player1Wins >= need {
+		m.WinnerID = &m.Player1ID
+	} else if m.Player2Wins >= need {
+		m.WinnerID = m.Player2ID
+	}
+}
+
+// applyTournamentGame — результат завершённой партии турнирного матча (в транзакции FinishGame).
+func applyTournamentGame(tx *gorm.DB, gameID uint) error {
+	var match models.TournamentMatch
+	err := tx.Where("game_id = ? AND winner_id IS NULL", gameID).First(&match).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Счёт матча меняется под блокировкой турнира и по перечитанному матчу — как в SetTournamentMatchResult.
+	var t models.Tournament
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&t, match.TournamentID).Error; err != nil {
+		return err
+	}
+	if err := tx.First(&match, match.ID).Error; err != nil {
+		return err
+	}
+	if match.WinnerID != nil || match.GameID == nil || *match.GameID != gameID {
+		return nil
+	}
+	var game models.Game
+	if err := tx.Preload("Players").First(&game, gameID).Error; err != nil {
+		return err
+	}
+	if game.WinningTeam == nil {
+		return nil
+	}
+	players := sortedGamePlayers(game.Players)
+	player1Won := false
+	for i, p := range players {
+		if p.UserID == match.Player1ID {
+			player1Won = playerTeam(i, len(players)) == *game.WinningTeam
+			break
+		}
+	}
+	recordTournamentMatchGame(t, &match, player1Won)
+	return tx.Model(&match).Select("player1_wins", "player2_wins", "winner_id").Updates(&match).Error
+}
+
+// loadTournament — турнир по :id с участниками и матчами; при ошибке пишет 400/404 и возвращает ok == false.
+func loadTournament(c *gin.Context, db *gorm.DB) (models.Tournament, bool) {
+	var t models.Tournament
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID турнира"})
+		return t, false
+	}
+	t, err = findTournament(db, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Турнир не найден"})
+		return t, false
+	}
+	return t, true
+}
+
+// findTournament — турнир с игроками (по порядку регистрации) и матчами (по раундам и столам).
+func findTournament(db *gorm.DB, id uint) (models.Tournament, error) {
+	var t models.Tournament
+	err := db.Preload("Players", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Matches", func(db *gorm.DB) *gorm.DB {
+		return db.Order("round ASC, table_number ASC")
+	}).First(&t, id).Error
+	return t, err
+}
+
+// lockTournament — блокирует строку турнира до конца транзакции и перечитывает турнир под блокировкой:
+// изменения раундов и счёта матчей не перетирают друг друга при параллельных запросах.
+func lockTournament(tx *gorm.DB, id uint) (models.Tournament, error) {
+	var locked models.Tournament
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, id).Error; err != nil {
+		return locked, err
+	}
+	return findTournament(tx, id)
+}
+
+// tournamentMatch — матч турнира t по ID; nil, если такого нет.
+func tournamentMatch(t *models.Tournament, id uint) *models.TournamentMatch {
+	for i := range t.Matches {
+		if t.Matches[i].ID == id {
+			return &t.Matches[i]
+		}
+	}
+	return nil
+}
+
+// loadTournamentMatch — матч :match_id турнира t; при ошибке пишет 400/404 и возвращает nil.
+func loadTournamentMatch(c *gin.Context, t *models.Tournament) *models.TournamentMatch {
+	id, err := strconv.Atoi(c.Param("match_id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID матча"})
+		return nil
+	}
+	if m := tournamentMatch(t, uint(id)); m != nil {
+		return m
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Матч не найден"})
+	return nil
+}
+
+func tournamentPlayer(t models.Tournament, userID uint) *models.TournamentPlayer {
+	for i := range t.Players {
+		if t.Players[i].UserID == userID {
+			return &t.Players[i]
+		}
+	}
+	return nil
+}
+
+func tournamentInLocation(t models.Tournament, loc *time.Location) models.Tournament {
+	t.CreatedAt = inLocation(t.CreatedAt, loc)
+	t.UpdatedAt = inLocation(t.UpdatedAt, loc)
+	for i := range t.Matches {
+		t.Matches[i].CreatedAt = inLocation(t.Matches[i].CreatedAt, loc)
+		t.Matches[i].UpdatedAt = inLocation(t.Matches[i].UpdatedAt, loc)
+	}
+	return t
+}
+
+func respondTournament(c *gin.Context, status int, t models.Tournament) {
+	_, loc, _ := resolveConfiguredTimezone()
+	c.JSON(status, models.TournamentResponse{
+		Tournament: tournamentInLocation(t, loc),
+		Standings:  computeTournamentStandings(t),
+	})
+}
+
+// GetTournaments — список турниров, новые сначала.
+func GetTournaments(c *gin.Context) {
+	db := database.GetDB()
+	var tournaments []models.Tournament
+	if err := db.Order("id DESC").Find(&tournaments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить список турниров"})
+		return
+	}
+	_, loc, _ := resolveConfiguredTimezone()
+	for i := range tournaments {
+		tournaments[i] = tournamentInLocation(tournaments[i], loc)
+	}
+	c.JSON(http.StatusOK, tournaments)
+}
+
+// GetTournament — турнир с участниками, матчами по раундам и таблицей швейцарской части.
+func GetTournament(c *gin.Context) {
+	t, ok := loadTournament(c, database.GetDB())
+	if !ok {
+		return
+	}
+	respondTournament(c, http.StatusOK, t)
+}
+
+// CreateTournament — создание турнира в статусе регистрации; только администратор.
+func CreateTournament(c *gin.Context) {
+	var req models.TournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные", "details": err.Error()})
+		return
+	}
+	if req.BestOf == 0 {
+		req.BestOf = 1
+	}
+	if req.BestOf != 1 && req.BestOf != 3 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "best_of должен быть 1 или 3"})
+		return
+	}
+	if req.SwissRounds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "swiss_rounds не может быть отрицательным"})
+		return
+	}
+	if req.TopCut != 0 && (req.TopCut < 2 || req.TopCut&(req.TopCut-1) != 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "top_cut должен быть 0 или степенью двойки (2, 4, 8…)"})
+		return
+	}
+	t := models.Tournament{
+		Name:        req.Name,
+		Status:      models.TournamentStatusRegistration,
+		BestOf:      req.BestOf,
+		SwissRounds: req.SwissRounds,
+		TopCut:      req.TopCut,
+	}
+	if err := database.GetDB().Create(&t).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать турнир"})
+		return
+	}
+	respondTournament(c, http.StatusCreated, t)
+}
+
+// DeleteTournament — удаление турнира с участниками и матчами; сыгранные партии остаются.
+func DeleteTournament(c *gin.Context) {
+	db := database.GetDB()
+	t, ok := loadTournament(c, db)
+	if !ok {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tournament_id = ?", t.ID).Delete(&models.TournamentMatch{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tournament_id = ?", t.ID).Delete(&models.TournamentPlayer{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tournament{}, t.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось удалить турнир"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Турнир удалён"})
+}
+
+// AddTournamentPlayer — регистрация игрока с колодой; только до первого раунда.
+func AddTournamentPlayer(c *gin.Context) {
+	var req models.TournamentPlayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные", "details": err.Error()})
+		return
+	}
+	db := database.GetDB()
+	t, ok := loadTournament(c, db)
+	if !ok {
+		return
+	}
+	if t.Status != models.TournamentStatusRegistration {
+		c.JSON(http.StatusConflict, gin.H{"error": "Регистрация закрыта"})
+		return
+	}
+	if tournamentPlayer(t, req.UserID) != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Игрок уже зарегистрирован"})
+		return
+	}
+	var user models.User
+	if err := db.First(&user, req.UserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+	var deck models.Deck
+	if err := db.First(&deck, req.DeckID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Колода не найдена"})
+		return
+	}
+	player := models.TournamentPlayer{
+		TournamentID: t.ID,
+		UserID:       user.ID,
+		PlayerName:   user.Name,
+		DeckID:       int(deck.ID),
+		DeckName:     deck.Name,
+	}
+	if err := db.Create(&player).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось зарегистрировать игрока"})
+		return
+	}
+	t.Players = append(t.Players, player)
+	respondTournament(c, http.StatusCreated, t)
+}
+
+// RemoveTournamentPlayer — отмена регистрации игрока; только до первого раунда.
+func RemoveTournamentPlayer(c *gin.Context) {
+	db := database.GetDB()
+	t, ok := loadTournament(c, db)
+	if !ok {
+		return
+	}
+	if t.Status != models.TournamentStatusRegistration {
+		c.JSON(http.StatusConflict, gin.H{"error": "Регистрация закрыта"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+	player := tournamentPlayer(t, uint(userID))
+	if player == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Игрок не зарегистрирован"})
+		return
+	}
+	if err := db.Delete(&models.TournamentPlayer{}, player.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось отменить регистрацию"})
+		return
+	}
+	players := make([]models.TournamentPlayer, 0, len(t.Players))
+	for _, p := range t.Players {
+		if p.ID != player.ID {
+			players = append(players, p)
+		}
+	}
+	t.Players = players
+	respondTournament(c, http.StatusOK, t)
+}
+
+// NextTournamentRound — следующий раунд: швейцарка, затем топ-кат на выбывание, затем завершение турнира.
+// 409, если текущий раунд не сыгран или турнир уже завершён.
+func NextTournamentRound(c *gin.Context) {
+	db := database.GetDB()
+	t, ok := loadTournament(c, db)
+	if !ok {
+		return
+	}
+	// Под блокировкой турнира параллельные запросы не сформируют один и тот же раунд дважды.
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockTournament(tx, t.ID)
+		if err != nil {
+			return err
+		}
+		t = current
+		if t.Status == models.TournamentStatusFinished {
+			return errTournamentFinished
+		}
+		if t.Status == models.TournamentStatusRegistration && len(t.Players) < 2 {
+			return errTournamentTooFewPlayers
+		}
+		return advanceTournament(tx, &t)
+	})
+	if errors.Is(err, errTournamentTooFewPlayers) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, errTournamentRoundIncomplete) || errors.Is(err, errTournamentFinished) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("NextTournamentRound: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сформировать раунд"})
+		return
+	}
+	respondTournament(c, http.StatusOK, t)
+}
+
+// StartTournamentMatchGame — активная игра для очередной партии матча (игрок 1 — команда 1).
+// Первый ход чередуется между партиями матча. 409, если матч завершён или уже есть активная игра.
+func StartTournamentMatchGame(c *gin.Context) {
+	var req models.TournamentMatchGameRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	db := database.GetDB()
+	t, ok := loadTournament(c, db)
+	if !ok {
+		return
+	}
+	match := loadTournamentMatch(c, &t)
+	if match == nil {
+		return
+	}
+	if match.Player2ID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "У бая нет партий"})
+		return
+	}
+	if match.WinnerID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Матч уже завершён"})
+		return
+	}
+	var active models.Game
+	if err := db.Where("end_time IS NULL").First(&active).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Активная игра уже существует"})
+		return
+	}
+	p1 := tournamentPlayer(t, match.Player1ID)
+	p2 := tournamentPlayer(t, *match.Player2ID)
+	if p1 == nil || p2 == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Игрок матча не зарегистрирован в турнире"})
+		return
+	}
+	var user1, user2 models.User
+	if db.First(&user1, p1.UserID).Error != nil || db.First(&user2, p2.UserID).Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+
+	token, err := uniqueViewToken(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сгенерировать публичный токен"})
+		return
+	}
+	firstMoveTeam := 1
+	if (match.Player1Wins+match.Player2Wins)%2 == 1 {
+		firstMoveTeam = 2
+	}
+	now := time.Now().UTC()
+	game := models.Game{
+		ViewToken:            token,
+		StartTime:            now,
+		TurnLimitSeconds:     req.TurnLimitSeconds,
+		TeamTimeLimitSeconds: req.TeamTimeLimitSeconds,
+		FirstMoveTeam:        firstMoveTeam,
+		Team1Name:            p1.PlayerName,
+		Team2Name:            p2.PlayerName,
+		CurrentTurnTeam:      firstMoveTeam,
+		Players: []models.GamePlayer{
+			{UserID: p1.UserID, User: user1, DeckID: p1.DeckID, DeckName: p1.DeckName},
+			{UserID: p2.UserID, User: user2, DeckID: p2.DeckID, DeckName: p2.DeckName},
+		},
+		Turns:     []models.GameTurn{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockTournament(tx, t.ID)
+		if err != nil {
+			return err
+		}
+		if m := tournamentMatch(&current, match.ID); m == nil || m.WinnerID != nil {
+			return errTournamentMatchFinished
+		}
+		if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Create(&game).Error; err != nil {
+			return err
+		}
+		return tx.Model(match).Update("game_id", game.ID).Error
+	})
+	if errors.Is(err, errTournamentMatchFinished) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("StartTournamentMatchGame: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать игру"})
+		return
+	}
+	invalidateStatsCache()
+
+	db.Scopes(withGameAssociations).First(&game, game.ID)
+	respondGame(c, http.StatusCreated, game)
+}
+
+// SetTournamentMatchResult — счёт матча, сыгранного вне приложения; ровно у одного игрока best_of/2+1 побед.
+func SetTournamentMatchResult(c *gin.Context) {
+	var req models.TournamentMatchResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные", "details": err.Error()})
+		return
+	}
+	db := database.GetDB()
+	t, ok := loadTournament(c, db)
+	if !ok {
+		return
+	}
+	match := loadTournamentMatch(c, &t)
+	if match == nil {
+		return
+	}
+	need := tournamentWinsNeeded(t)
+	p1, p2 := req.Player1Wins, req.Player2Wins
+	if p1 < 0 || p2 < 0 || (p1 == need) == (p2 == need) || p1 > need || p2 > need {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Счёт должен давать победу ровно одному игроку (до " + strconv.Itoa(need) + " побед)"})
+		return
+	}
+	// Матч перечитывается под блокировкой турнира: результат не перетрёт партию, завершённую параллельно.
+	matchID := match.ID
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockTournament(tx, t.ID)
+		if err != nil {
+			return err
+		}
+		t = current
+		match := tournamentMatch(&t, matchID)
+		if match == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if match.Player2ID == nil || match.WinnerID != nil {
+			return errTournamentMatchFinished
+		}
+		if match.GameID != nil {
+			var game models.Game
+			if err := tx.First(&game, *match.GameID).Error; err == nil && game.EndTime == nil {
+				return errTournamentMatchGameActive
+			}
+		}
+		match.Player1Wins, match.Player2Wins = p1, p2
+		if p1 == need {
+			match.WinnerID = &match.Player1ID
+		} else {
+			match.WinnerID = match.Player2ID
+		}
+		return tx.Model(match).Select("player1_wins", "player2_wins", "winner_id").Updates(match).Error
+	})
+	if errors.Is(err, errTournamentMatchFinished) || errors.Is(err, errTournamentMatchGameActive) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Матч не найден"})
+		return
+	}
+	if err != nil {
+		log.Printf("SetTournamentMatchResult: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить результат матча"})
+		return
+	}
+	respondTournament(c, http.StatusOK, t)
+}
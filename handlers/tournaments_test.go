@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"mtg-stats-backend/models"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+// swissMatch — завершённый матч швейцарской части; player2 == 0 — бай.
+func swissMatch(round int, player1, player2 uint, wins1, wins2 int) models.TournamentMatch {
+	m := models.TournamentMatch{
+		Round:       round,
+		Stage:       models.TournamentStageSwiss,
+		Player1ID:   player1,
+		Player1Wins: wins1,
+		Player2Wins: wins2,
+	}
+	if player2 != 0 {
+		m.Player2ID = uintPtr(player2)
+	}
+	if player2 == 0 || wins1 > wins2 {
+		m.WinnerID = uintPtr(player1)
+	} else {
+		m.WinnerID = uintPtr(player2)
+	}
+	return m
+}
+
+func testTournament(bestOf int, names map[uint]string, matches ...models.TournamentMatch) models.Tournament {
+	t := models.Tournament{BestOf: bestOf, Matches: matches}
+	for id := uint(1); id <= uint(len(names)); id++ {
+		t.Players = append(t.Players, models.TournamentPlayer{UserID: id, PlayerName: names[id]})
+	}
+	return t
+}
+
+func TestBracketSeedOrder(t *testing.T) {
+	tests := []struct {
+		size int
+		want []int
+	}{
+		{2, []int{1, 2}},
+		{4, []int{1, 4, 2, 3}},
+		{8, []int{1, 8, 4, 5, 2, 7, 3, 6}},
+	}
+	for _, tt := range tests {
+		if got := bracketSeedOrder(tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("bracketSeedOrder(%d) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestPairSwiss(t *testing.T) {
+	played := func(pairs ...[2]uint) map[userPair]bool {
+		m := make(map[userPair]bool)
+		for _, p := range pairs {
+			m[newUserPair(p[0], p[1])] = true
+		}
+		return m
+	}
+	tests := []struct {
+		name         string
+		ids          []uint
+		played       map[userPair]bool
+		allowRematch bool
+		want         [][2]uint
+		wantOK       bool
+	}{
+		{
+			name:   "top-down without history",
+			ids:    []uint{1, 2, 3, 4},
+			played: played(),
+			want:   [][2]uint{{1, 2}, {3, 4}},
+			wantOK: true,
+		},
+		{
+			name:   "skips rematch",
+			ids:    []uint{1, 2, 3, 4},
+			played: played([2]uint{1, 2}),
+			want:   [][2]uint{{1, 3}, {2, 4}},
+			wantOK: true,
+		},
+		{
+			name:   "backtracks when rest cannot be paired",
+			ids:    []uint{1, 2, 3, 4},
+			played: played([2]uint{1, 2}, [2]uint{2, 4}),
+			want:   [][2]uint{{1, 4}, {2, 3}},
+			wantOK: true,
+		},
+		{
+			name:   "fails when only rematches remain",
+			ids:    []uint{1, 2, 3, 4},
+			played: played([2]uint{1, 2}, [2]uint{1, 3}, [2]uint{1, 4}),
+			wantOK: false,
+		},
+		{
+			name:         "fallback allows rematch",
+			ids:          []uint{1, 2, 3, 4},
+			played:       played([2]uint{1, 2}, [2]uint{1, 3}, [2]uint{1, 4}),
+			allowRematch: true,
+			want:         [][2]uint{{1, 2}, {3, 4}},
+			wantOK:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pairSwiss(tt.ids, tt.played, tt.allowRematch)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pairs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwissRoundMatchesBye(t *testing.T) {
+	tests := []struct {
+		name       string
+		tournament models.Tournament
+		round      int
+		wantPairs  [][2]uint
+		wantBye    uint
+	}{
+		{
+			name: "lowest ranked player gets the bye",
+			tournament: testTournament(1, map[uint]string{1: "Ann", 2: "Cid", 3: "Ben"},
+				swissMatch(1, 1, 2, 1, 0),
+				swissMatch(1, 3, 0, 1, 0),
+			),
+			round:     2,
+			wantPairs: [][2]uint{{1, 3}},
+			wantBye:   2,
+		},
+		{
+			name: "players who had a bye are skipped",
+			tournament: testTournament(1, map[uint]string{1: "Ann", 2: "Cid", 3: "Ben"},
+				swissMatch(1, 1, 2, 1, 0),
+				swissMatch(1, 3, 0, 1, 0),
+				swissMatch(2, 1, 3, 1, 0),
+				swissMatch(2, 2, 0, 1, 0),
+			),
+			round:     3,
+			wantPairs: [][2]uint{{3, 2}},
+			wantBye:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := swissRoundMatches(tt.tournament, tt.round)
+			var pairs [][2]uint
+			var bye uint
+			for i, m := range matches {
+				if m.Round != tt.round || m.TableNumber != i+1 {
+					t.Errorf("match %d: round %d table %d", i, m.Round, m.TableNumber)
+				}
+				if m.Player2ID == nil {
+					bye = m.Player1ID
+					if m.WinnerID == nil || *m.WinnerID != bye || m.Player1Wins != tournamentWinsNeeded(tt.tournament) {
+						t.Errorf("bye match is not a win for player %d: %+v", bye, m)
+					}
+					continue
+				}
+				pairs = append(pairs, [2]uint{m.Player1ID, *m.Player2ID})
+			}
+			if bye != tt.wantBye {
+				t.Errorf("bye = %d, want %d", bye, tt.wantBye)
+			}
+			if !reflect.DeepEqual(pairs, tt.wantPairs) {
+				t.Errorf("pairs = %v, want %v", pairs, tt.wantPairs)
+			}
+			if last := matches[len(matches)-1]; last.Player2ID != nil {
+				t.Errorf("bye match must be the last table, got %+v", last)
+			}
+		})
+	}
+}
+
+func TestComputeTournamentStandings(t *testing.T) {
+	const floor = 100.0 / 3
+	tests := []struct {
+		name       string
+		tournament models.Tournament
+		want       []models.TournamentStanding
+	}{
+		{
+			name: "33% floor on match and game win rates",
+			tournament: testTournament(3, map[uint]string{1: "Ann", 2: "Bob", 3: "Cat", 4: "Dan"},
+				swissMatch(1, 1, 2, 2, 0),
+				swissMatch(1, 3, 4, 2, 1),
+			),
+			want: []models.TournamentStanding{
+				{Rank: 1, UserID: 1, MatchPoints: 3, MatchWins: 1, MatchWinRate: 100, OMWRate: floor, GameWinRate: 100, OGWRate: floor},
+				{Rank: 2, UserID: 3, MatchPoints: 3, MatchWins: 1, MatchWinRate: 100, OMWRate: floor, GameWinRate: 200.0 / 3, OGWRate: floor},
+				{Rank: 3, UserID: 2, MatchLosses: 1, MatchWinRate: floor, OMWRate: 100, GameWinRate: floor, OGWRate: 100},
+				{Rank: 4, UserID: 4, MatchLosses: 1, MatchWinRate: floor, OMWRate: 100, GameWinRate: floor, OGWRate: 200.0 / 3},
+			},
+		},
+		{
+			name: "bye counts as a match win without an opponent",
+			tournament: testTournament(1, map[uint]string{1: "Ann", 2: "Bob", 3: "Cat"},
+				swissMatch(1, 2, 3, 1, 0),
+				swissMatch(1, 1, 0, 1, 0),
+			),
+			want: []models.TournamentStanding{
+				{Rank: 1, UserID: 2, MatchPoints: 3, MatchWins: 1, MatchWinRate: 100, OMWRate: floor, GameWinRate: 100, OGWRate: floor},
+				{Rank: 2, UserID: 1, MatchPoints: 3, MatchWins: 1, Byes: 1, MatchWinRate: 100, GameWinRate: 100},
+				{Rank: 3, UserID: 3, MatchLosses: 1, MatchWinRate: floor, OMWRate: 100, GameWinRate: floor, OGWRate: 100},
+			},
+		},
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeTournamentStandings(tt.tournament)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d standings, want %d", len(got), len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Rank != w.Rank || g.UserID != w.UserID || g.MatchPoints != w.MatchPoints ||
+					g.MatchWins != w.MatchWins || g.MatchLosses != w.MatchLosses || g.Byes != w.Byes {
+					t.Errorf("standing %d = %+v, want %+v", i, g, w)
+					continue
+				}
+				if !near(g.MatchWinRate, w.MatchWinRate) || !near(g.OMWRate, w.OMWRate) ||
+					!near(g.GameWinRate, w.GameWinRate) || !near(g.OGWRate, w.OGWRate) {
+					t.Errorf("standing %d rates = %.4f/%.4f/%.4f/%.4f, want %.4f/%.4f/%.4f/%.4f", i,
+						g.MatchWinRate, g.OMWRate, g.GameWinRate, g.OGWRate,
+						w.MatchWinRate, w.OMWRate, w.GameWinRate, w.OGWRate)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"mtg-stats-backend/database"
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// profileRecentGames — партий в форме и списке последних игр профиля.
+	profileRecentGames = 10
+	// profileMinGames — минимум партий для лучшей колоды, немезиды и любимой жертвы.
+	profileMinGames = 3
+)
+
+func profileRecord(games, wins int) models.ProfileRecord {
+	return models.ProfileRecord{GamesCount: games, WinsCount: wins, LossesCount: games - wins, WinRate: winPercent(wins, games)}
+}
+
+// profileTally — счётчик игр и побед игрока профиля по ключу (колода, соперник, напарник).
+type profileTally struct {
+	name        string
+	games, wins int
+}
+
+func tallyAdd(m map[int]*profileTally, key int, name string, won bool) {
+	t := m[key]
+	if t == nil {
+		t = &profileTally{name: name}
+		m[key] = t
+	}
+	t.games++
+	if won {
+		t.wins++
+	}
+}
+
+// pickTally — ключ лучшей по better записи среди записей от minGames партий; false, если таких нет.
+// При равенстве — больше партий, затем меньший ключ (детерминированный выбор).
+func pickTally(m map[int]*profileTally, minGames int, better func(a, b *profileTally) bool) (int, bool) {
+	keys := make([]int, 0, len(m))
+	for k, t := range m {
+		if t.games >= minGames {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, false
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := m[keys[i]], m[keys[j]]
+		if better(a, b) {
+			return true
+		}
+		if better(b, a) {
+			return false
+		}
+		if a.games != b.games {
+			return a.games > b.games
+		}
+		return keys[i] < keys[j]
+	})
+	return keys[0], true
+}
+
+func tallyRate(t *profileTally) float64 {
+	return float64(t.wins) / float64(t.games)
+}
+
+func profileOpponent(m map[int]*profileTally, key int, ok bool) *models.ProfileOpponent {
+	if !ok {
+		return nil
+	}
+	t := m[key]
+	return &models.ProfileOpponent{UserID: uint(key), PlayerName: t.name, ProfileRecord: profileRecord(t.games, t.wins)}
+}
+
+func profileDeck(m map[int]*profileTally, key int, ok bool) *models.ProfileDeck {
+	if !ok {
+		return nil
+	}
+	t := m[key]
+	return &models.ProfileDeck{DeckID: key, DeckName: t.name, ProfileRecord: profileRecord(t.games, t.wins)}
+}
+
+// GetUserProfile — профиль игрока: общая статистика и форма за последние партии, рейтинг, любимая и лучшая колоды,
+// немезида и любимая жертва, самый частый напарник, последние партии и активность по месяцам.
+// Поддерживает общие фильтры (statsFilter).
+func GetUserProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+	filter, ok := parseStatsFilter(c)
+	if !ok {
+		return
+	}
+	if writeStatsCacheHit(c) {
+		return
+	}
+
+	db := database.GetDB()
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+
+	type participantRow struct {
+		GameID      uint      `gorm:"column:game_id"`
+		UserID      uint      `gorm:"column:user_id"`
+		PlayerName  string    `gorm:"column:player_name"`
+		DeckID      int       `gorm:"column:deck_id"`
+		DeckName    string    `gorm:"column:deck_name"`
+		StartTime   time.Time `gorm:"column:start_time"`
+		EndTime     time.Time `gorm:"column:end_time"`
+		WinningTeam int       `gorm:"column:winning_team"`
+		PlayerTeam  int       `gorm:"column:player_team"`
+	}
+	whereClause, whereArgs := buildCompletedGamesWhereClause("g", filter)
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT game_id, user_id, player_name, deck_id, deck_name, start_time, end_time, winning_team, player_team
+		FROM players_with_team
+		WHERE game_id IN (SELECT game_id FROM game_players WHERE user_id = ?)
+		ORDER BY end_time DESC, game_id DESC, player_index ASC
+	`, playersWithTeamCTE(whereClause))
+	args := append(append([]interface{}{}, whereArgs...), user.ID)
+	var rows []participantRow
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		log.Printf("GetUserProfile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить профиль игрока"})
+		return
+	}
+
+	// Партии от новых к старым, участники — в порядке добавления.
+	var gameOrder []uint
+	byGame := make(map[uint][]participantRow)
+	for _, r := range rows {
+		if _, seen := byGame[r.GameID]; !seen {
+			gameOrder = append(gameOrder, r.GameID)
+		}
+		byGame[r.GameID] = append(byGame[r.GameID], r)
+	}
+
+	loc := filter.Location
+	resp := models.UserProfileResponse{
+		UserID:          user.ID,
+		PlayerName:      user.Name,
+		ProfileMinGames: profileMinGames,
+		RecentGames:     make([]models.ProfileGame, 0, profileRecentGames),
+		Activity:        []models.ProfileActivity{},
+	}
+	decks := make(map[int]*profileTally)
+	opponents := make(map[int]*profileTally)
+	teammates := make(map[int]*profileTally)
+	activity := make(map[string]*models.ProfileActivity)
+	var wins, recentGames, recentWins int
+	var form []byte
+	for _, gameID := range gameOrder {
+		participants := byGame[gameID]
+		var me *participantRow
+		for i := range participants {
+			if participants[i].UserID == user.ID {
+				me = &participants[i]
+				break
+			}
+		}
+		if me == nil {
+			continue
+		}
+		won := me.PlayerTeam == me.WinningTeam
+		if won {
+			wins++
+		}
+		tallyAdd(decks, me.DeckID, me.DeckName, won)
+
+		game := models.ProfileGame{
+			GameID:      gameID,
+			StartTime:   inLocation(me.StartTime, loc),
+			EndTime:     inLocation(me.EndTime, loc),
+			DeckID:      me.DeckID,
+			DeckName:    me.DeckName,
+			Team:        me.PlayerTeam,
+			WinningTeam: me.WinningTeam,
+			Won:         won,
+			Teammates:   []string{},
+			Opponents:   []string{},
+		}
+		for _, p := range participants {
+			if p.UserID == user.ID {
+				continue
+			}
+			if p.PlayerTeam == me.PlayerTeam {
+				tallyAdd(teammates, int(p.UserID), p.PlayerName, won)
+				game.Teammates = append(game.Teammates, p.PlayerName)
+			} else {
+				tallyAdd(opponents, int(p.UserID), p.PlayerName, won)
+				game.Opponents = append(game.Opponents, p.PlayerName)
+			}
+		}
+		if len(resp.RecentGames) < profileRecentGames {
+			resp.RecentGames = append(resp.RecentGames, game)
+			recentGames++
+			if won {
+				recentWins++
+				form = append(form, 'W')
+			} else {
+				form = append(form, 'L')
+			}
+		}
+
+		month := periodKey(me.StartTime, "month", loc)
+		a := activity[month]
+		if a == nil {
+			a = &models.ProfileActivity{Month: month}
+			activity[month] = a
+		}
+		a.GamesCount++
+		if won {
+			a.WinsCount++
+		}
+	}
+
+	games := 0
+	for _, t := range decks {
+		games += t.games
+	}
+	resp.Overall = profileRecord(games, wins)
+	resp.RecentForm = models.ProfileForm{ProfileRecord: profileRecord(recentGames, recentWins), Form: string(form)}
+
+	key, ok := pickTally(decks, 1, func(a, b *profileTally) bool { return a.games > b.games })
+	resp.FavouriteDeck = profileDeck(decks, key, ok)
+	key, ok = pickTally(decks, profileMinGames, func(a, b *profileTally) bool { return tallyRate(a) > tallyRate(b) })
+	resp.BestDeck = profileDeck(decks, key, ok)
+	key, ok = pickTally(opponents, profileMinGames, func(a, b *profileTally) bool { return tallyRate(a) < tallyRate(b) })
+	resp.Nemesis = profileOpponent(opponents, key, ok)
+	key, ok = pickTally(opponents, profileMinGames, func(a, b *profileTally) bool { return tallyRate(a) > tallyRate(b) })
+	resp.FavouriteVictim = profileOpponent(opponents, key, ok)
+	key, ok = pickTally(teammates, 1, func(a, b *profileTally) bool { return a.games > b.games })
+	resp.TopTeammate = profileOpponent(teammates, key, ok)
+
+	for _, a := range activity {
+		resp.Activity = append(resp.Activity, *a)
+	}
+	sort.Slice(resp.Activity, func(i, j int) bool { return resp.Activity[i].Month < resp.Activity[j].Month })
+
+	var rating models.PlayerRating
+	err = db.Where("user_id = ?", user.ID).First(&rating).Error
+	if err == nil {
+		stats := playerRatingStats(rating, user.Name, loc)
+		resp.Rating = &stats
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("GetUserProfile: rating: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить рейтинг"})
+		return
+	}
+
+	writeStatsCacheJSON(c, resp)
+}
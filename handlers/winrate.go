@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+
+	"mtg-stats-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// wilsonZ — квантиль нормального распределения для 95% интервала Уилсона.
+	wilsonZ = 1.96
+	// bayesPriorGames — сила априорного распределения: столько «виртуальных» игр с 50% побед добавляется к выборке.
+	bayesPriorGames = 10.0
+	// metaTopWinRateMinGames — порог игр для топа по проценту побед мета-дашборда, если min_games не задан.
+	metaTopWinRateMinGames = 3
+)
+
+// winPercent — процент побед; без игр — 0.
+func winPercent(wins, games int) float64 {
+	if games == 0 {
+		return 0
+	}
+	return float64(wins) / float64(games) * 100
+}
+
+// wilsonInterval — 95% интервал Уилсона для доли побед, в процентах. Без игр — весь диапазон 0..100.
+func wilsonInterval(wins, games int) (float64, float64) {
+	if games <= 0 {
+		return 0, 100
+	}
+	n := float64(games)
+	p := float64(wins) / n
+	z2 := wilsonZ * wilsonZ
+	denom := 1 + z2/n
+	center := (p + z2/(2*n)) / denom
+	margin := wilsonZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denom
+	return math.Max(0, center-margin) * 100, math.Min(1, center+margin) * 100
+}
+
+// bayesianWinRate — процент побед, сглаженный к 50% априорными bayesPriorGames играми (бета-распределение).
+func bayesianWinRate(wins, games int) float64 {
+	return (float64(wins) + bayesPriorGames/2) / (float64(games) + bayesPriorGames) * 100
+}
+
+func winRateConfidence(wins, games int) models.WinRateConfidence {
+	low, high := wilsonInterval(wins, games)
+	return models.WinRateConfidence{
+		WinRateLow:      low,
+		WinRateHigh:     high,
+		BayesianWinRate: bayesianWinRate(wins, games),
+	}
+}
+
+// winRateSortOrders — допустимые значения sort для статистики по процентам побед.
+var winRateSortOrders = map[string]bool{
+	"win_rate":     true,
+	"win_rate_low": true,
+	"bayesian":     true,
+	"games":        true,
+}
+
+// parseWinRateSort — параметр sort (win_rate|win_rate_low|bayesian|games); пустая строка — порядок эндпоинта.
+// При ошибке пишет 400 и возвращает ok == false.
+func parseWinRateSort(c *gin.Context) (string, bool) {
+	order := c.Query("sort")
+	if order != "" && !winRateSortOrders[order] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort должен быть win_rate|win_rate_low|bayesian|games"})
+		return "", false
+	}
+	return order, true
+}
+
+// winRateSortValue — значение, по которому строка сортируется по убыванию для выбранного порядка.
+func winRateSortValue(order string, wins, games int) float64 {
+	switch order {
+	case "win_rate_low":
+		low, _ := wilsonInterval(wins, games)
+		return low
+	case "bayesian":
+		return bayesianWinRate(wins, games)
+	case "games":
+		return float64(games)
+	default:
+		if games == 0 {
+			return 0
+		}
+		return float64(wins) / float64(games)
+	}
+}
+
+// sortByWinRate стабильно сортирует срез rows по убыванию winRateSortValue; stat(i) — победы и игры i-й строки.
+func sortByWinRate(order string, rows interface{}, stat func(i int) (wins, games int)) {
+	if order == "" {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		wi, gi := stat(i)
+		wj, gj := stat(j)
+		return winRateSortValue(order, wi, gi) > winRateSortValue(order, wj, gj)
+	})
+}
@@ -45,7 +45,8 @@ func corsMiddleware(isLocal bool) gin.HandlerFunc {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 		}
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, If-Match")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusOK)
@@ -101,6 +102,8 @@ func main() {
 	if err := database.InitDB(); err != nil {
 		log.Fatalf("Ошибка БД: %v", err)
 	}
+	handlers.EnsureStatsAggregates(database.GetDB())
+	handlers.StartLeaderboardSnapshots(database.GetDB())
 
 	ginMode := os.Getenv("GIN_MODE")
 	if ginMode == "" {
@@ -131,38 +134,80 @@ func main() {
 			"auth":      apiToken != "",
 			"auth_hint": "При auth=true все /api/* требуют заголовок: Authorization: Bearer <API_TOKEN или JWT>",
 			"endpoints": gin.H{
-				"POST /api/auth/login":              "Вход (name, password) → JWT",
-				"GET /api/users":                    "Список пользователей",
-				"GET /api/users/:id":                "Пользователь по ID",
-				"POST /api/users":                   "Создать пользователя",
-				"PUT /api/users/:id":                "Обновить пользователя",
-				"DELETE /api/users/:id":             "Удалить пользователя",
-				"GET /api/decks":                    "Список колод",
-				"GET /api/decks/:id":                "Колода по ID",
-				"POST /api/decks":                   "Создать колоду",
-				"PUT /api/decks/:id":                "Обновить колоду",
-				"POST /api/decks/:id/image":         "Загрузить изображение и аватар колоды (multipart: image, avatar)",
-				"DELETE /api/decks/:id/image":       "Удалить изображение и аватар колоды",
-				"DELETE /api/decks/:id":             "Удалить колоду",
-				"GET /api/games":                    "Список игр",
-				"GET /api/games/active":             "Активная игра",
-				"POST /api/games/active/start-turn": "Начать ход (серверное время)",
-				"GET /api/games/:id":                "Игра по ID",
-				"POST /api/games":                   "Создать игру",
-				"PUT /api/games/active":             "Обновить активную игру",
-				"POST /api/games/active/finish":     "Завершить активную игру",
-				"GET /api/stats/players":            "Статистика игроков",
-				"GET /api/stats/decks":              "Статистика колод",
-				"GET /api/stats/deck-matchups":      "Матрица матчапов колод",
-				"GET /api/stats/meta-dashboard":     "Мета-дашборд (группировка day/week/month)",
-				"POST /api/games/rematch":           "Создать быстрый реванш на основе завершённой игры",
-				"GET /api/public/games/:token":      "Публичный read-only просмотр игры по токену",
-				"GET /api/settings":                 "Текущие настройки приложения (timezone)",
-				"PUT /api/settings":                 "Обновить настройки приложения (timezone, только админ)",
-				"GET /api/export/all":               "Экспорт всех данных (пользователи, колоды, игры, изображения в base64) в gzip-архиве JSON",
-				"POST /api/import/all":              "Полная замена всех данных из gzip-архива JSON",
-				"DELETE /api/games":                 "Полная очистка игр и ходов",
-				"GET /health":                       "Проверка состояния",
+				"POST /api/auth/login":                               "Вход (name, password) → JWT",
+				"GET /api/users":                                     "Список пользователей",
+				"GET /api/users/:id":                                 "Пользователь по ID",
+				"POST /api/users":                                    "Создать пользователя",
+				"PUT /api/users/:id":                                 "Обновить пользователя",
+				"DELETE /api/users/:id":                              "Удалить пользователя",
+				"GET /api/users/:id/achievements":                    "Достижения пользователя: открытые и закрытые",
+				"GET /api/users/:id/profile":                         "Профиль игрока: статистика, форма, рейтинг, колоды, соперники, последние игры, активность",
+				"POST /api/achievements/recompute":                   "Полный пересчёт достижений (только админ)",
+				"GET /api/decks":                                     "Список колод",
+				"GET /api/decks/:id":                                 "Колода по ID",
+				"GET /api/decks/:id/profile":                         "Профиль колоды: процент побед по периодам, матчапы, пилоты, длительность, первый ход, последние игры",
+				"POST /api/decks":                                    "Создать колоду",
+				"PUT /api/decks/:id":                                 "Обновить колоду",
+				"POST /api/decks/:id/image":                          "Загрузить изображение и аватар колоды (multipart: image, avatar)",
+				"DELETE /api/decks/:id/image":                        "Удалить изображение и аватар колоды",
+				"DELETE /api/decks/:id":                              "Удалить колоду",
+				"GET /api/games":                                     "Список игр (user_id, limit)",
+				"GET /api/games/active":                              "Активная игра",
+				"POST /api/games/active/start-turn":                  "Начать ход (серверное время)",
+				"GET /api/games/:id":                                 "Игра по ID",
+				"GET /api/games/:id/timeline":                        "Хронология партии: ходы, паузы, завершение, накопленное время команд",
+				"POST /api/games":                                    "Создать игру",
+				"POST /api/games/completed":                          "Записать уже сыгранную игру задним числом (время, ходы, победитель)",
+				"PUT /api/games/active":                              "Обновить активную игру",
+				"POST /api/games/active/finish":                      "Завершить активную игру",
+				"GET /api/stats/players":                             "Статистика игроков",
+				"GET /api/stats/player-decks":                        "Матрица игрок × колода (user_id — колоды одного игрока)",
+				"GET /api/stats/decks":                               "Статистика колод (с рейтингом колоды)",
+				"GET /api/stats/deck-matchups":                       "Матрица матчапов колод",
+				"GET /api/stats/deck-pairs":                          "Пары колод в одной команде и матчапы составов 2v2 (min_games)",
+				"GET /api/stats/meta-dashboard":                      "Мета-дашборд (группировка day/week/month, изменения к прошлому периоду, растущие и падающие колоды, smooth)",
+				"GET /api/stats/pauses":                              "Частота пауз и потерянное время по играм",
+				"GET /api/stats/turns":                               "Длительность ходов: распределения, овертайм, тренды и связь с победой",
+				"GET /api/stats/game-length":                         "Длительность партий и число ходов по колодам, матчапам, числу игроков и периодам",
+				"GET /api/stats/game-length/active":                  "Прогноз оставшегося времени активной партии",
+				"GET /api/stats/head-to-head":                        "Очные встречи двух игроков (user_a, user_b, from/to, limit)",
+				"GET /api/stats/teammates":                           "Пары игроков в одной команде (sort=best|worst, user_id, min_games)",
+				"GET /api/stats/ratings":                             "Рейтинги Glicko-2 игроков (rating, deviation)",
+				"GET /api/stats/ratings/:user_id/history":            "История рейтинга игрока по играм",
+				"POST /api/stats/ratings/recompute":                  "Полный пересчёт рейтингов (только админ)",
+				"GET /api/leaderboard":                               "Таблица лидеров (metric=win_rate|rating|games_played) с движением относительно снимка (snapshot_id)",
+				"GET /api/leaderboard/snapshots":                     "Список снимков таблицы лидеров",
+				"POST /api/leaderboard/snapshots":                    "Сделать снимок таблицы лидеров (только админ)",
+				"GET /api/leaderboard/history/:user_id":              "История мест игрока по снимкам (metric)",
+				"POST /api/stats/rebuild":                            "Полная пересборка агрегатов статистики (только админ)",
+				"GET /api/seasons":                                   "Список сезонов",
+				"GET /api/seasons/:id":                               "Сезон по ID",
+				"GET /api/seasons/:id/standings":                     "Турнирная таблица сезона (у закрытого — замороженный снимок)",
+				"POST /api/seasons":                                  "Создать сезон (name, start_date, end_date, points_win, points_loss)",
+				"PUT /api/seasons/:id":                               "Обновить открытый сезон",
+				"DELETE /api/seasons/:id":                            "Удалить сезон",
+				"POST /api/seasons/:id/close":                        "Закрыть сезон и заморозить таблицу",
+				"GET /api/tournaments":                               "Список турниров",
+				"GET /api/tournaments/:id":                           "Турнир: участники, матчи по раундам и таблица (очки, OMW%, GW%, OGW%)",
+				"POST /api/tournaments":                              "Создать турнир (name, best_of, swiss_rounds, top_cut)",
+				"DELETE /api/tournaments/:id":                        "Удалить турнир",
+				"POST /api/tournaments/:id/players":                  "Зарегистрировать игрока с колодой (user_id, deck_id)",
+				"DELETE /api/tournaments/:id/players/:user_id":       "Отменить регистрацию игрока",
+				"POST /api/tournaments/:id/rounds":                   "Следующий раунд: швейцарка, топ-кат на выбывание, завершение",
+				"POST /api/tournaments/:id/matches/:match_id/game":   "Начать партию матча как активную игру",
+				"POST /api/tournaments/:id/matches/:match_id/result": "Внести счёт матча, сыгранного вне приложения",
+				"POST /api/games/rematch":                            "Создать быстрый реванш на основе завершённой игры",
+				"POST /api/games/next-in-match":                      "Следующая партия матча (Bo3/Bo5) по завершённой игре",
+				"GET /api/matches":                                   "Список матчей из нескольких партий",
+				"GET /api/matches/:id":                               "Матч: стороны, партии и счёт",
+				"GET /api/public/games/:token":                       "Публичный read-only просмотр игры по токену",
+				"GET /api/settings":                                  "Текущие настройки приложения (timezone)",
+				"PUT /api/settings":                                  "Обновить настройки приложения (timezone, только админ)",
+				"GET /api/export/all":                                "Экспорт всех данных (пользователи, колоды, игры, изображения в base64) в gzip-архиве JSON",
+				"POST /api/import/all":                               "Полная замена всех данных из gzip-архива JSON",
+				"DELETE /api/games":                                  "Полная очистка игр и ходов",
+				"GET /api/time":                                      "Время сервера для синхронизации часов клиента (client_send_ms → t1/t2)",
+				"GET /health":                                        "Проверка состояния",
 			},
 		})
 	})
@@ -175,14 +220,37 @@ func main() {
 	{
 		publicAPI.GET("/decks", handlers.GetDecks)
 		publicAPI.GET("/decks/:id", handlers.GetDeck)
+		publicAPI.GET("/decks/:id/profile", handlers.GetDeckProfile)
 		publicAPI.GET("/games", handlers.GetGames)
 		publicAPI.GET("/games/:id", handlers.GetGame)
+		publicAPI.GET("/games/:id/timeline", handlers.GetGameTimeline)
 		publicAPI.GET("/games/active", handlers.GetActiveGame)
 		publicAPI.GET("/stats/players", handlers.GetPlayerStats)
+		publicAPI.GET("/stats/player-decks", handlers.GetPlayerDeckStats)
 		publicAPI.GET("/stats/decks", handlers.GetDeckStats)
 		publicAPI.GET("/stats/deck-matchups", handlers.GetDeckMatchups)
+		publicAPI.GET("/stats/deck-pairs", handlers.GetDeckPairs)
 		publicAPI.GET("/stats/meta-dashboard", handlers.GetMetaDashboard)
+		publicAPI.GET("/stats/pauses", handlers.GetPauseStats)
+		publicAPI.GET("/stats/turns", handlers.GetTurnStats)
+		publicAPI.GET("/stats/game-length", handlers.GetGameLengthStats)
+		publicAPI.GET("/stats/game-length/active", handlers.GetActiveGameEstimate)
+		publicAPI.GET("/stats/head-to-head", handlers.GetHeadToHead)
+		publicAPI.GET("/stats/teammates", handlers.GetTeammateStats)
+		publicAPI.GET("/stats/ratings", handlers.GetPlayerRatings)
+		publicAPI.GET("/stats/ratings/:user_id/history", handlers.GetPlayerRatingHistory)
+		publicAPI.GET("/leaderboard", handlers.GetLeaderboard)
+		publicAPI.GET("/leaderboard/snapshots", handlers.GetLeaderboardSnapshots)
+		publicAPI.GET("/leaderboard/history/:user_id", handlers.GetLeaderboardHistory)
+		publicAPI.GET("/seasons", handlers.GetSeasons)
+		publicAPI.GET("/seasons/:id", handlers.GetSeason)
+		publicAPI.GET("/seasons/:id/standings", handlers.GetSeasonStandings)
+		publicAPI.GET("/matches", handlers.GetMatches)
+		publicAPI.GET("/matches/:id", handlers.GetMatch)
+		publicAPI.GET("/tournaments", handlers.GetTournaments)
+		publicAPI.GET("/tournaments/:id", handlers.GetTournament)
 		publicAPI.GET("/settings", handlers.GetSettings)
+		publicAPI.GET("/time", handlers.GetServerTime)
 	}
 
 	publicReadOnly := router.Group("/api/public")
@@ -198,6 +266,9 @@ func main() {
 		api.POST("/users", middleware.RequireAdmin(), handlers.CreateUser)
 		api.PUT("/users/:id", middleware.RequireUser(), handlers.UpdateUser)
 		api.DELETE("/users/:id", middleware.RequireAdmin(), handlers.DeleteUser)
+		api.GET("/users/:id/achievements", handlers.GetUserAchievements)
+		api.GET("/users/:id/profile", handlers.GetUserProfile)
+		api.POST("/achievements/recompute", middleware.RequireAdmin(), handlers.RecomputeAchievements)
 
 		api.POST("/decks", middleware.RequireAdmin(), handlers.CreateDeck)
 		api.PUT("/decks/:id", middleware.RequireAdmin(), handlers.UpdateDeck)
@@ -207,6 +278,8 @@ func main() {
 
 		api.POST("/games", middleware.RequireAdmin(), handlers.CreateGame)
 		api.POST("/games/rematch", middleware.RequireAdmin(), handlers.CreateRematch)
+		api.POST("/games/next-in-match", middleware.RequireAdmin(), handlers.CreateNextMatchGame)
+		api.POST("/games/completed", middleware.RequireAdmin(), handlers.CreateCompletedGame)
 		api.DELETE("/games", middleware.RequireAdmin(), handlers.ClearGamesAndTurns)
 		api.PUT("/games/active", middleware.RequireAdmin(), handlers.UpdateActiveGame)
 		api.POST("/games/active/pause", middleware.RequireAdmin(), handlers.PauseGame)
@@ -214,6 +287,22 @@ func main() {
 		api.POST("/games/active/start-turn", middleware.RequireAdmin(), handlers.StartTurn)
 		api.POST("/games/active/finish", middleware.RequireAdmin(), handlers.FinishGame)
 
+		api.POST("/seasons", middleware.RequireAdmin(), handlers.CreateSeason)
+		api.PUT("/seasons/:id", middleware.RequireAdmin(), handlers.UpdateSeason)
+		api.DELETE("/seasons/:id", middleware.RequireAdmin(), handlers.DeleteSeason)
+		api.POST("/seasons/:id/close", middleware.RequireAdmin(), handlers.CloseSeason)
+		api.POST("/tournaments", middleware.RequireAdmin(), handlers.CreateTournament)
+		api.DELETE("/tournaments/:id", middleware.RequireAdmin(), handlers.DeleteTournament)
+		api.POST("/tournaments/:id/players", middleware.RequireAdmin(), handlers.AddTournamentPlayer)
+		api.DELETE("/tournaments/:id/players/:user_id", middleware.RequireAdmin(), handlers.RemoveTournamentPlayer)
+		api.POST("/tournaments/:id/rounds", middleware.RequireAdmin(), handlers.NextTournamentRound)
+		api.POST("/tournaments/:id/matches/:match_id/game", middleware.RequireAdmin(), handlers.StartTournamentMatchGame)
+		api.POST("/tournaments/:id/matches/:match_id/result", middleware.RequireAdmin(), handlers.SetTournamentMatchResult)
+
+		api.POST("/stats/ratings/recompute", middleware.RequireAdmin(), handlers.RecomputeRatings)
+		api.POST("/leaderboard/snapshots", middleware.RequireAdmin(), handlers.CreateLeaderboardSnapshot)
+		api.POST("/stats/rebuild", middleware.RequireAdmin(), handlers.RebuildStats)
+
 		api.PUT("/settings", middleware.RequireAdmin(), handlers.UpdateSettings)
 
 		api.GET("/export/all", middleware.RequireAdmin(), handlers.ExportAllData)
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// UserAchievement — открытое игроком достижение и партия, после которой оно открылось.
+type UserAchievement struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_achievement"`
+	Code       string    `json:"code" gorm:"size:50;not null;uniqueIndex:idx_user_achievement"`
+	GameID     uint      `json:"game_id" gorm:"not null;index"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+}
+
+func (UserAchievement) TableName() string { return "user_achievements" }
+
+// AchievementStatus — достижение в профиле игрока; game_id и unlocked_at — только у открытых.
+type AchievementStatus struct {
+	Code        string     `json:"code"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Unlocked    bool       `json:"unlocked"`
+	GameID      *uint      `json:"game_id,omitempty"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
+}
+
+// UserAchievementsResponse — ответ /api/users/:id/achievements.
+type UserAchievementsResponse struct {
+	UserID        uint                `json:"user_id"`
+	PlayerName    string              `json:"player_name"`
+	UnlockedCount int                 `json:"unlocked_count"`
+	TotalCount    int                 `json:"total_count"`
+	Achievements  []AchievementStatus `json:"achievements"`
+}
@@ -20,17 +20,33 @@ type GamePlayer struct {
 
 func (GamePlayer) TableName() string { return "game_players" }
 
-// GameTurn — ход в игре: команда, длительность и овертайм (сек).
+// GameTurn — ход в игре: команда, длительность и овертайм (сек), время начала и конца (серверное).
+// started_at/ended_at пусты у ходов, записанных до появления этих полей.
 type GameTurn struct {
-	ID         uint `json:"id" gorm:"primaryKey"`
-	GameID     uint `json:"-" gorm:"not null;index"`
-	TeamNumber int  `json:"team_number"`
-	Duration   int  `json:"duration_sec"`
-	Overtime   int  `json:"overtime_sec"`
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	GameID     uint       `json:"-" gorm:"not null;index"`
+	TeamNumber int        `json:"team_number"`
+	Duration   int        `json:"duration_sec"`
+	Overtime   int        `json:"overtime_sec"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
 }
 
 func (GameTurn) TableName() string { return "game_turns" }
 
+// GamePause — интервал паузы партии (кто и зачем поставил); ended_at == nil — пауза идёт сейчас.
+type GamePause struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	GameID      uint       `json:"-" gorm:"not null;index"`
+	StartedAt   time.Time  `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+	Reason      string     `json:"reason,omitempty" gorm:"size:255"`
+	ActorUserID *uint      `json:"actor_user_id,omitempty"`
+	ActorName   string     `json:"actor_name,omitempty" gorm:"size:100"`
+}
+
+func (GamePause) TableName() string { return "game_pauses" }
+
 // GamePlayerResponse — игрок в ответе API; user.is_admin маскируется для не-админов.
 type GamePlayerResponse struct {
 	ID       uint         `json:"id"`
@@ -40,6 +56,7 @@ type GamePlayerResponse struct {
 }
 
 // GameResponse — игра в ответе API; players[].user.is_admin маскируется для не-админов.
+// server_now — время сервера на момент ответа, чтобы таймеры на разных устройствах считались от одних часов.
 type GameResponse struct {
 	ID                        uint                  `json:"id"`
 	PublicViewToken           string                `json:"public_view_token,omitempty"`
@@ -51,6 +68,7 @@ type GameResponse struct {
 	Team2Name                 string                `json:"team2_name,omitempty"`
 	Players                   []GamePlayerResponse  `json:"players"`
 	Turns                     []GameTurn            `json:"turns"`
+	Pauses                    []GamePause           `json:"pauses"`
 	CurrentTurnTeam           int                   `json:"current_turn_team"`
 	CurrentTurnStart          *time.Time            `json:"current_turn_start,omitempty"`
 	IsPaused                  bool                  `json:"is_paused"`
@@ -59,11 +77,26 @@ type GameResponse struct {
 	TeamTimeLimitSeconds      int                   `json:"team_time_limit_seconds"`
 	IsTechnicalDefeat         bool                  `json:"is_technical_defeat"`
 	WinningTeam               *int                  `json:"winning_team,omitempty"`
+	MatchID                   *uint                 `json:"match_id,omitempty"`
+	Version                   int64                 `json:"version"`
+	ETag                      string                `json:"etag"`
+	ServerNow                 time.Time             `json:"server_now"`
 	CreatedAt                 time.Time             `json:"created_at"`
 	UpdatedAt                 time.Time             `json:"updated_at"`
 }
 
+// ClockSyncResponse — ответ /api/time по схеме NTP: t0 — отправка клиентом (эхо), t1 — приём сервером, t2 — отправка сервером.
+// Клиент фиксирует t3 при получении ответа: смещение = ((t1 - t0) + (t2 - t3)) / 2, задержка = (t3 - t0) - (t2 - t1).
+type ClockSyncResponse struct {
+	ClientSendMs      *int64    `json:"client_send_ms,omitempty"`
+	ServerReceiveMs   int64     `json:"server_receive_ms"`
+	ServerSendMs      int64     `json:"server_send_ms"`
+	ServerReceiveTime time.Time `json:"server_receive_time"`
+	ServerSendTime    time.Time `json:"server_send_time"`
+}
+
 // Game — партия (игроки, ходы, лимит времени); end_time == nil — активная игра; winning_team 1 или 2.
+// Version растёт при каждом изменении состояния партии (оптимистичная блокировка, ETag).
 type Game struct {
 	ID                        uint         `json:"id" gorm:"primaryKey"`
 	ViewToken                 string       `json:"-" gorm:"size:64;uniqueIndex"`
@@ -75,6 +108,7 @@ type Game struct {
 	Team2Name                 string       `json:"team2_name,omitempty"`
 	Players                   []GamePlayer `json:"players" gorm:"foreignKey:GameID"`
 	Turns                     []GameTurn   `json:"turns" gorm:"foreignKey:GameID"`
+	Pauses                    []GamePause  `json:"pauses" gorm:"foreignKey:GameID"`
 	CurrentTurnTeam           int          `json:"current_turn_team"`
 	CurrentTurnStart           *time.Time   `json:"current_turn_start,omitempty"`
 	IsPaused                  bool         `json:"is_paused"`
@@ -83,6 +117,8 @@ type Game struct {
 	TeamTimeLimitSeconds      int          `json:"team_time_limit_seconds"`
 	IsTechnicalDefeat         bool         `json:"is_technical_defeat"`
 	WinningTeam               *int         `json:"winning_team,omitempty"`
+	MatchID                   *uint        `json:"match_id,omitempty" gorm:"index"`
+	Version                   int64        `json:"version" gorm:"not null;default:1"`
 	CreatedAt                 time.Time    `json:"created_at"`
 	UpdatedAt                 time.Time    `json:"updated_at"`
 }
@@ -134,10 +170,34 @@ type CreateGameRequest struct {
 	Players               []CreateGamePlayerInput `json:"players"`
 }
 
+// CreateCompletedGameRequest — ретроспективная запись уже сыгранной игры: время начала/конца задаёт клиент,
+// ходы необязательны, winning_team 1 или 2.
+type CreateCompletedGameRequest struct {
+	TurnLimitSeconds          int                     `json:"turn_limit_seconds"`
+	TeamTimeLimitSeconds      int                     `json:"team_time_limit_seconds"`
+	FirstMoveTeam             int                     `json:"first_move_team"`
+	Team1Name                 string                  `json:"team1_name,omitempty"`
+	Team2Name                 string                  `json:"team2_name,omitempty"`
+	Players                   []CreateGamePlayerInput `json:"players"`
+	StartTime                 *flexTime               `json:"start_time"`
+	EndTime                   *flexTime               `json:"end_time"`
+	Turns                     []GameTurnInput         `json:"turns,omitempty"`
+	TotalPauseDurationSeconds int                     `json:"total_pause_duration_seconds"`
+	WinningTeam               int                     `json:"winning_team"`
+	IsTechnicalDefeat         bool                    `json:"is_technical_defeat"`
+}
+
 // FinishGameRequest — завершение игры; winning_team 1 или 2.
+// expected_version — альтернатива заголовку If-Match.
 type FinishGameRequest struct {
-	WinningTeam       int  `json:"winning_team"`
-	IsTechnicalDefeat bool `json:"is_technical_defeat"`
+	WinningTeam       int    `json:"winning_team"`
+	IsTechnicalDefeat bool   `json:"is_technical_defeat"`
+	ExpectedVersion   *int64 `json:"expected_version,omitempty"`
+}
+
+// PauseGameRequest — необязательное тело паузы: причина.
+type PauseGameRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
 }
 
 // RematchRequest — запрос на быстрый реванш.
@@ -152,6 +212,14 @@ type RematchRequest struct {
 // flexTime — время из JSON (RFC3339, RFC3339Nano, ISO8601 от Flutter).
 type flexTime struct{ T *time.Time }
 
+// Time — значение или nil, если поле не передано.
+func (f *flexTime) Time() *time.Time {
+	if f == nil {
+		return nil
+	}
+	return f.T
+}
+
 func (f *flexTime) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), `"`)
 	if s == "" || s == "null" {
@@ -181,11 +249,22 @@ func (f *flexTime) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// GameTurnInput — ход в запросе; started_at/ended_at необязательны — у нового хода сервер проставляет их сам.
+type GameTurnInput struct {
+	TeamNumber int       `json:"team_number"`
+	Duration   int       `json:"duration_sec"`
+	Overtime   int       `json:"overtime_sec"`
+	StartedAt  *flexTime `json:"started_at,omitempty"`
+	EndedAt    *flexTime `json:"ended_at,omitempty"`
+}
+
 // UpdateActiveGameRequest — обновление активной игры (текущий ход, ходы).
+// expected_version — альтернатива заголовку If-Match.
 type UpdateActiveGameRequest struct {
-	CurrentTurnTeam  int      `json:"current_turn_team"`
-	CurrentTurnStart *flexTime `json:"current_turn_start,omitempty"`
-	Turns            []GameTurn `json:"turns"`
+	CurrentTurnTeam  int             `json:"current_turn_team"`
+	CurrentTurnStart *flexTime       `json:"current_turn_start,omitempty"`
+	Turns            []GameTurnInput `json:"turns"`
+	ExpectedVersion  *int64          `json:"expected_version,omitempty"`
 }
 
 // PlayerStats — агрегат по игроку (ответ /api/stats/players).
@@ -206,42 +285,146 @@ type PlayerStats struct {
 	CurrentLossStreak   *int    `json:"current_loss_streak,omitempty"`
 	MaxWinStreak        *int    `json:"max_win_streak,omitempty"`
 	MaxLossStreak       *int    `json:"max_loss_streak,omitempty"`
+	MatchesCount        int     `json:"matches_count"`
+	MatchWins           int     `json:"match_wins"`
+	MatchWinPercent     float64 `json:"match_win_percent"`
+	WinRateConfidence
+}
+
+// GamePauseStat — паузы одной завершённой партии.
+type GamePauseStat struct {
+	GameID      uint       `json:"game_id"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     *time.Time `json:"end_time,omitempty"`
+	PausesCount int        `json:"pauses_count"`
+	PauseSec    int        `json:"pause_sec"`
+}
+
+// PauseReasonStat — паузы, сгруппированные по причине.
+type PauseReasonStat struct {
+	Reason        string `json:"reason"`
+	PausesCount   int    `json:"pauses_count"`
+	TotalPauseSec int    `json:"total_pause_sec"`
+}
+
+// PauseStatsResponse — частота пауз и потерянное время (ответ /api/stats/pauses).
+type PauseStatsResponse struct {
+	TotalGames          int               `json:"total_games"`
+	GamesWithPauses     int               `json:"games_with_pauses"`
+	TotalPauses         int               `json:"total_pauses"`
+	PausesPerGame       float64           `json:"pauses_per_game"`
+	TotalPauseSec       int               `json:"total_pause_sec"`
+	AvgPauseSecPerGame  float64           `json:"avg_pause_sec_per_game"`
+	AvgPauseDurationSec float64           `json:"avg_pause_duration_sec"`
+	ByReason            []PauseReasonStat `json:"by_reason"`
+	Games               []GamePauseStat   `json:"games"`
 }
 
-// DeckStats — агрегат по колоде (ответ /api/stats/decks).
+// TimelineEvent — событие хронологии партии (game_start, turn, pause, finish).
+// team1_clock_sec/team2_clock_sec — накопленное время ходов команд после события; estimated — время хода восстановлено по длительностям.
+type TimelineEvent struct {
+	Type          string     `json:"type"`
+	At            time.Time  `json:"at"`
+	EndAt         *time.Time `json:"end_at,omitempty"`
+	ElapsedSec    int        `json:"elapsed_sec"`
+	TurnNumber    int        `json:"turn_number,omitempty"`
+	TeamNumber    int        `json:"team_number,omitempty"`
+	DurationSec   int        `json:"duration_sec,omitempty"`
+	OvertimeSec   int        `json:"overtime_sec,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	WinningTeam   *int       `json:"winning_team,omitempty"`
+	Estimated     bool       `json:"estimated,omitempty"`
+	Team1ClockSec int        `json:"team1_clock_sec"`
+	Team2ClockSec int        `json:"team2_clock_sec"`
+}
+
+// GameTimelineResponse — хронология партии (ответ /api/games/:id/timeline).
+type GameTimelineResponse struct {
+	GameID            uint            `json:"game_id"`
+	StartTime         time.Time       `json:"start_time"`
+	EndTime           *time.Time      `json:"end_time,omitempty"`
+	Team1Name         string          `json:"team1_name,omitempty"`
+	Team2Name         string          `json:"team2_name,omitempty"`
+	WinningTeam       *int            `json:"winning_team,omitempty"`
+	IsTechnicalDefeat bool            `json:"is_technical_defeat"`
+	TotalDurationSec  int             `json:"total_duration_sec"`
+	TotalPauseSec     int             `json:"total_pause_sec"`
+	PlayDurationSec   int             `json:"play_duration_sec"`
+	Team1ClockSec     int             `json:"team1_clock_sec"`
+	Team2ClockSec     int             `json:"team2_clock_sec"`
+	Events            []TimelineEvent `json:"events"`
+}
+
+// DeckStats — агрегат по колоде (ответ /api/stats/decks); rating — рейтинг колоды с поправкой на пилотов.
 type DeckStats struct {
-	DeckID     int     `json:"deck_id"`
-	DeckName   string  `json:"deck_name"`
-	GamesCount int     `json:"games_count"`
-	WinsCount  int     `json:"wins_count"`
-	WinPercent float64 `json:"win_percent"`
+	DeckID          int      `json:"deck_id"`
+	DeckName        string   `json:"deck_name"`
+	GamesCount      int      `json:"games_count"`
+	WinsCount       int      `json:"wins_count"`
+	WinPercent      float64  `json:"win_percent"`
+	Rating          *float64 `json:"rating,omitempty"`
+	RatingDeviation *float64 `json:"rating_deviation,omitempty"`
+	MatchesCount    int      `json:"matches_count"`
+	MatchWins       int      `json:"match_wins"`
+	MatchWinPercent float64  `json:"match_win_percent"`
+	WinRateConfidence
 }
 
-// DeckMatchupStats — статистика матчапа пары колод.
+// DeckMatchupStats — статистика матчапа пары колод; интервалы и байесовская оценка — с точки зрения каждой колоды.
 type DeckMatchupStats struct {
-	Deck1ID      int     `json:"deck1_id"`
-	Deck1Name    string  `json:"deck1_name"`
-	Deck2ID      int     `json:"deck2_id"`
-	Deck2Name    string  `json:"deck2_name"`
-	GamesCount   int     `json:"games_count"`
-	Deck1Wins    int     `json:"deck1_wins"`
-	Deck2Wins    int     `json:"deck2_wins"`
-	Deck1WinRate float64 `json:"deck1_win_rate"`
-	Deck2WinRate float64 `json:"deck2_win_rate"`
+	Deck1ID              int     `json:"deck1_id"`
+	Deck1Name            string  `json:"deck1_name"`
+	Deck2ID              int     `json:"deck2_id"`
+	Deck2Name            string  `json:"deck2_name"`
+	GamesCount           int     `json:"games_count"`
+	Deck1Wins            int     `json:"deck1_wins"`
+	Deck2Wins            int     `json:"deck2_wins"`
+	Deck1WinRate         float64 `json:"deck1_win_rate"`
+	Deck2WinRate         float64 `json:"deck2_win_rate"`
+	Deck1WinRateLow      float64 `json:"deck1_win_rate_low"`
+	Deck1WinRateHigh     float64 `json:"deck1_win_rate_high"`
+	Deck1BayesianWinRate float64 `json:"deck1_bayesian_win_rate"`
+	Deck2WinRateLow      float64 `json:"deck2_win_rate_low"`
+	Deck2WinRateHigh     float64 `json:"deck2_win_rate_high"`
+	Deck2BayesianWinRate float64 `json:"deck2_bayesian_win_rate"`
 }
 
 type DeckMatchupsResponse struct {
 	Matchups []DeckMatchupStats `json:"matchups"`
 }
 
-// MetaDeckStat — агрегат по колоде в мета-срезе.
+// MetaDeckStat — агрегат по колоде в мета-срезе; rating — текущий рейтинг колоды с поправкой на пилотов.
 type MetaDeckStat struct {
-	DeckID     int     `json:"deck_id"`
-	DeckName   string  `json:"deck_name"`
-	GamesCount int     `json:"games_count"`
-	WinsCount  int     `json:"wins_count"`
-	WinRate    float64 `json:"win_rate"`
-	MetaShare  float64 `json:"meta_share"`
+	DeckID          int      `json:"deck_id"`
+	DeckName        string   `json:"deck_name"`
+	GamesCount      int      `json:"games_count"`
+	WinsCount       int      `json:"wins_count"`
+	WinRate         float64  `json:"win_rate"`
+	MetaShare       float64  `json:"meta_share"`
+	Rating          *float64 `json:"rating,omitempty"`
+	RatingDeviation *float64 `json:"rating_deviation,omitempty"`
+	// Изменение к предыдущему периоду (только в periods): доля меты — от 0, если колоды там не было;
+	// процент побед — только если колода там играла.
+	MetaShareDelta *float64 `json:"meta_share_delta,omitempty"`
+	WinRateDelta   *float64 `json:"win_rate_delta,omitempty"`
+	// Скользящее среднее за smooth последних периодов (только при smooth > 1).
+	SmoothedMetaShare *float64 `json:"smoothed_meta_share,omitempty"`
+	SmoothedWinRate   *float64 `json:"smoothed_win_rate,omitempty"`
+	WinRateConfidence
+}
+
+// MetaTrendDeck — изменение доли меты колоды между двумя последними периодами (растущие и падающие колоды).
+type MetaTrendDeck struct {
+	DeckID            int      `json:"deck_id"`
+	DeckName          string   `json:"deck_name"`
+	Period            string   `json:"period"`
+	PreviousPeriod    string   `json:"previous_period"`
+	GamesCount        int      `json:"games_count"`
+	MetaShare         float64  `json:"meta_share"`
+	PreviousMetaShare float64  `json:"previous_meta_share"`
+	MetaShareDelta    float64  `json:"meta_share_delta"`
+	WinRate           *float64 `json:"win_rate,omitempty"`
+	WinRateDelta      *float64 `json:"win_rate_delta,omitempty"`
 }
 
 // MetaPeriodStats — статистика по одному временному периоду.
@@ -256,9 +439,13 @@ type MetaDashboardResponse struct {
 	FromDate      string            `json:"from_date,omitempty"`
 	ToDate        string            `json:"to_date,omitempty"`
 	GroupBy       string            `json:"group_by"`
+	Timezone      string            `json:"timezone"`
 	TotalGames    int               `json:"total_games"`
 	UniqueDecks   int               `json:"unique_decks"`
 	TopPlayedDecks []MetaDeckStat   `json:"top_played_decks"`
 	TopWinRateDecks []MetaDeckStat  `json:"top_win_rate_decks"`
 	Periods       []MetaPeriodStats `json:"periods"`
+	SmoothWindow    int             `json:"smooth_window,omitempty"`
+	TopRisingDecks  []MetaTrendDeck `json:"top_rising_decks"`
+	TopFallingDecks []MetaTrendDeck `json:"top_falling_decks"`
 }
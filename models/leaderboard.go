@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Метрики таблицы лидеров.
+const (
+	LeaderboardMetricWinRate     = "win_rate"
+	LeaderboardMetricRating      = "rating"
+	LeaderboardMetricGamesPlayed = "games_played"
+)
+
+// LeaderboardSnapshot — снимок таблицы лидеров по всем метрикам на момент taken_at.
+type LeaderboardSnapshot struct {
+	ID      uint               `json:"id" gorm:"primaryKey"`
+	TakenAt time.Time          `json:"taken_at" gorm:"not null;index"`
+	Entries []LeaderboardEntry `json:"entries,omitempty" gorm:"foreignKey:SnapshotID;constraint:OnDelete:CASCADE"`
+}
+
+func (LeaderboardSnapshot) TableName() string { return "leaderboard_snapshots" }
+
+// LeaderboardEntry — место игрока в снимке по одной метрике; value — процент побед, рейтинг или число игр.
+type LeaderboardEntry struct {
+	ID         uint    `json:"-" gorm:"primaryKey"`
+	SnapshotID uint    `json:"-" gorm:"not null;index:idx_leaderboard_entry"`
+	Metric     string  `json:"metric" gorm:"size:20;not null;index:idx_leaderboard_entry"`
+	UserID     uint    `json:"user_id" gorm:"not null;index:idx_leaderboard_entry"`
+	PlayerName string  `json:"player_name" gorm:"size:100"`
+	Rank       int     `json:"rank"`
+	Value      float64 `json:"value"`
+	GamesCount int     `json:"games_count"`
+}
+
+func (LeaderboardEntry) TableName() string { return "leaderboard_entries" }
+
+// LeaderboardRow — место в текущей таблице и движение относительно снимка:
+// rank_delta > 0 — поднялся, < 0 — опустился; is_new — игрока не было в снимке.
+type LeaderboardRow struct {
+	Rank         int     `json:"rank"`
+	UserID       uint    `json:"user_id"`
+	PlayerName   string  `json:"player_name"`
+	Value        float64 `json:"value"`
+	GamesCount   int     `json:"games_count"`
+	PreviousRank *int    `json:"previous_rank,omitempty"`
+	RankDelta    *int    `json:"rank_delta,omitempty"`
+	IsNew        bool    `json:"is_new"`
+}
+
+// LeaderboardResponse — текущая таблица по метрике; compared_to — снимок для сравнения (нет, если снимков ещё нет).
+type LeaderboardResponse struct {
+	Metric     string               `json:"metric"`
+	ComparedTo *LeaderboardSnapshot `json:"compared_to,omitempty"`
+	Entries    []LeaderboardRow     `json:"entries"`
+}
+
+// LeaderboardRankPoint — место игрока в одном снимке.
+type LeaderboardRankPoint struct {
+	SnapshotID uint      `json:"snapshot_id"`
+	TakenAt    time.Time `json:"taken_at"`
+	Rank       int       `json:"rank"`
+	Value      float64   `json:"value"`
+	GamesCount int       `json:"games_count"`
+}
+
+// LeaderboardHistoryResponse — история мест игрока по снимкам (старые сначала).
+type LeaderboardHistoryResponse struct {
+	UserID uint                   `json:"user_id"`
+	Metric string                 `json:"metric"`
+	Points []LeaderboardRankPoint `json:"points"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Match — матч из нескольких партий одних и тех же сторон (best_of 3 по умолчанию).
+// Команды матча — команды его партий (порядок игроков в партиях матча сохраняется);
+// winning_team выставляется, когда одна из сторон набирает best_of/2+1 побед.
+type Match struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	BestOf      int       `json:"best_of" gorm:"not null"`
+	Team1Wins   int       `json:"team1_wins"`
+	Team2Wins   int       `json:"team2_wins"`
+	WinningTeam *int      `json:"winning_team,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Match) TableName() string { return "matches" }
+
+// MatchGameSummary — партия матча в ответе: номер, время, первый ход и победитель.
+type MatchGameSummary struct {
+	GameID            uint       `json:"game_id"`
+	GameNumber        int        `json:"game_number"`
+	StartTime         time.Time  `json:"start_time"`
+	EndTime           *time.Time `json:"end_time,omitempty"`
+	FirstMoveTeam     int        `json:"first_move_team"`
+	WinningTeam       *int       `json:"winning_team,omitempty"`
+	IsTechnicalDefeat bool       `json:"is_technical_defeat"`
+}
+
+// MatchResponse — матч со сторонами (по первой партии) и партиями по порядку.
+type MatchResponse struct {
+	Match
+	Team1Name string               `json:"team1_name,omitempty"`
+	Team2Name string               `json:"team2_name,omitempty"`
+	Players   []GamePlayerResponse `json:"players"`
+	Games     []MatchGameSummary   `json:"games"`
+}
+
+// NextMatchGameRequest — следующая партия матча по завершённой source_game_id.
+// Если партия ещё не в матче, создаётся матч best_of (3 или 5, по умолчанию 3) с ней в качестве первой партии.
+type NextMatchGameRequest struct {
+	SourceGameID uint `json:"source_game_id"`
+	BestOf       int  `json:"best_of"`
+}
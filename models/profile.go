@@ -0,0 +1,120 @@
+package models
+
+import "time"
+
+// ProfileRecord — игры, победы и процент побед.
+type ProfileRecord struct {
+	GamesCount  int     `json:"games_count"`
+	WinsCount   int     `json:"wins_count"`
+	LossesCount int     `json:"losses_count"`
+	WinRate     float64 `json:"win_rate"`
+}
+
+// ProfileForm — форма по последним партиям: form — результаты от новых к старым (W — победа, L — поражение).
+type ProfileForm struct {
+	ProfileRecord
+	Form string `json:"form"`
+}
+
+// ProfileDeck — колода игрока в профиле.
+type ProfileDeck struct {
+	DeckID   int    `json:"deck_id"`
+	DeckName string `json:"deck_name"`
+	ProfileRecord
+}
+
+// ProfileOpponent — соперник или напарник; record — результаты игрока профиля в этих партиях.
+type ProfileOpponent struct {
+	UserID     uint   `json:"user_id"`
+	PlayerName string `json:"player_name"`
+	ProfileRecord
+}
+
+// ProfileGame — партия игрока: его колода и команда, напарники, соперники и исход.
+type ProfileGame struct {
+	GameID      uint      `json:"game_id"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	DeckID      int       `json:"deck_id"`
+	DeckName    string    `json:"deck_name"`
+	Team        int       `json:"team"`
+	WinningTeam int       `json:"winning_team"`
+	Won         bool      `json:"won"`
+	Teammates   []string  `json:"teammates"`
+	Opponents   []string  `json:"opponents"`
+}
+
+// ProfileActivity — игры и победы за месяц (YYYY-MM в часовом поясе фильтра tz).
+type ProfileActivity struct {
+	Month      string `json:"month"`
+	GamesCount int    `json:"games_count"`
+	WinsCount  int    `json:"wins_count"`
+}
+
+// UserProfileResponse — ответ /api/users/:id/profile. Nemesis — соперник, против которого у игрока худший процент побед,
+// favourite_victim — лучший (оба — от profile_min_games совместных партий); top_teammate — самый частый напарник.
+type UserProfileResponse struct {
+	UserID          uint               `json:"user_id"`
+	PlayerName      string             `json:"player_name"`
+	Overall         ProfileRecord      `json:"overall"`
+	RecentForm      ProfileForm        `json:"recent_form"`
+	Rating          *PlayerRatingStats `json:"rating,omitempty"`
+	FavouriteDeck   *ProfileDeck       `json:"favourite_deck,omitempty"`
+	BestDeck        *ProfileDeck       `json:"best_deck,omitempty"`
+	Nemesis         *ProfileOpponent   `json:"nemesis,omitempty"`
+	FavouriteVictim *ProfileOpponent   `json:"favourite_victim,omitempty"`
+	TopTeammate     *ProfileOpponent   `json:"top_teammate,omitempty"`
+	ProfileMinGames int                `json:"profile_min_games"`
+	RecentGames     []ProfileGame      `json:"recent_games"`
+	Activity        []ProfileActivity  `json:"activity"`
+}
+
+// DeckProfilePeriod — результаты колоды за период и накопленный процент побед к его концу.
+type DeckProfilePeriod struct {
+	Period string `json:"period"`
+	ProfileRecord
+	CumulativeWinRate float64 `json:"cumulative_win_rate"`
+}
+
+// DeckProfileMatchup — колода против колоды соперника (строка матрицы матчапов с точки зрения колоды, включая зеркало).
+type DeckProfileMatchup struct {
+	OpponentDeckID   int    `json:"opponent_deck_id"`
+	OpponentDeckName string `json:"opponent_deck_name"`
+	ProfileRecord
+	WinRateConfidence
+}
+
+// DeckProfilePilot — игрок, игравший колодой, и его результаты на ней.
+type DeckProfilePilot struct {
+	UserID     uint   `json:"user_id"`
+	PlayerName string `json:"player_name"`
+	ProfileRecord
+}
+
+// DeckProfileGame — партия с участием колоды: пилоты, колоды соперников и исход для колоды.
+type DeckProfileGame struct {
+	GameID        uint      `json:"game_id"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Pilots        []string  `json:"pilots"`
+	OpponentDecks []string  `json:"opponent_decks"`
+	Won           bool      `json:"won"`
+}
+
+// DeckProfileResponse — ответ /api/decks/:id/profile. Результаты считаются по участиям колоды в партиях
+// (как в /api/stats/decks); first_move/second_move — партии, где команда колоды ходила первой или второй.
+type DeckProfileResponse struct {
+	DeckID          int                  `json:"deck_id"`
+	DeckName        string               `json:"deck_name"`
+	Overall         ProfileRecord        `json:"overall"`
+	GroupBy         string               `json:"group_by"`
+	WinRateOverTime []DeckProfilePeriod  `json:"win_rate_over_time"`
+	MatchupMinGames int                  `json:"matchup_min_games"`
+	BestMatchups    []DeckProfileMatchup `json:"best_matchups"`
+	WorstMatchups   []DeckProfileMatchup `json:"worst_matchups"`
+	Pilots          []DeckProfilePilot   `json:"pilots"`
+	GameLength      GameLengthSummary    `json:"game_length"`
+	FirstMove       ProfileRecord        `json:"first_move"`
+	SecondMove      ProfileRecord        `json:"second_move"`
+	RecentGames     []DeckProfileGame    `json:"recent_games"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// PlayerRating — текущий рейтинг Glicko-2 игрока (rating, deviation, volatility).
+type PlayerRating struct {
+	UserID     uint       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Rating     float64    `json:"rating"`
+	Deviation  float64    `json:"deviation"`
+	Volatility float64    `json:"volatility"`
+	GamesCount int        `json:"games_count"`
+	LastGameAt *time.Time `json:"last_game_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (PlayerRating) TableName() string { return "player_ratings" }
+
+// PlayerRatingHistory — изменение рейтинга игрока по итогам одной игры.
+type PlayerRatingHistory struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	UserID          uint      `json:"user_id" gorm:"not null;index"`
+	GameID          uint      `json:"game_id" gorm:"not null;index"`
+	PlayedAt        time.Time `json:"played_at"`
+	Won             bool      `json:"won"`
+	RatingBefore    float64   `json:"rating_before"`
+	RatingAfter     float64   `json:"rating_after"`
+	DeviationBefore float64   `json:"deviation_before"`
+	DeviationAfter  float64   `json:"deviation_after"`
+	Volatility      float64   `json:"volatility"`
+}
+
+func (PlayerRatingHistory) TableName() string { return "player_rating_history" }
+
+// PlayerRatingStats — рейтинг игрока (ответ /api/stats/ratings).
+// conservative_rating = rating - 2·deviation — нижняя оценка силы, удобна для сортировки новичков.
+type PlayerRatingStats struct {
+	UserID             uint       `json:"user_id"`
+	PlayerName         string     `json:"player_name"`
+	Rating             float64    `json:"rating"`
+	Deviation          float64    `json:"deviation"`
+	Volatility         float64    `json:"volatility"`
+	ConservativeRating float64    `json:"conservative_rating"`
+	GamesCount         int        `json:"games_count"`
+	LastGameAt         *time.Time `json:"last_game_at,omitempty"`
+}
+
+// RatingHistoryPoint — точка траектории рейтинга после игры.
+type RatingHistoryPoint struct {
+	GameID          uint      `json:"game_id"`
+	PlayedAt        time.Time `json:"played_at"`
+	Won             bool      `json:"won"`
+	RatingBefore    float64   `json:"rating_before"`
+	RatingAfter     float64   `json:"rating_after"`
+	RatingChange    float64   `json:"rating_change"`
+	DeviationBefore float64   `json:"deviation_before"`
+	DeviationAfter  float64   `json:"deviation_after"`
+}
+
+// PlayerRatingHistoryResponse — история рейтинга игрока (ответ /api/stats/ratings/:user_id/history).
+type PlayerRatingHistoryResponse struct {
+	UserID     uint                 `json:"user_id"`
+	PlayerName string               `json:"player_name"`
+	Current    *PlayerRatingStats   `json:"current,omitempty"`
+	History    []RatingHistoryPoint `json:"history"`
+}
+
+// DeckRating — рейтинг Glicko-2 колоды с поправкой на силу пилота.
+type DeckRating struct {
+	DeckID     int        `json:"deck_id" gorm:"primaryKey;autoIncrement:false"`
+	Rating     float64    `json:"rating"`
+	Deviation  float64    `json:"deviation"`
+	Volatility float64    `json:"volatility"`
+	GamesCount int        `json:"games_count"`
+	LastGameAt *time.Time `json:"last_game_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (DeckRating) TableName() string { return "deck_ratings" }
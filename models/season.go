@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Season — сезон: игры с датой начала в [start_date, end_date] (YYYY-MM-DD в настроенном часовом поясе).
+// Очки таблицы: points_win за победу и points_loss за поражение. closed_at != nil — сезон закрыт,
+// таблица заморожена в season_standings и больше не пересчитывается.
+type Season struct {
+	ID         uint             `json:"id" gorm:"primaryKey"`
+	Name       string           `json:"name" gorm:"size:100;not null"`
+	StartDate  string           `json:"start_date" gorm:"size:10;not null"`
+	EndDate    string           `json:"end_date" gorm:"size:10;not null"`
+	PointsWin  int              `json:"points_win" gorm:"not null"`
+	PointsLoss int              `json:"points_loss" gorm:"not null"`
+	ClosedAt   *time.Time       `json:"closed_at,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	Standings  []SeasonStanding `json:"standings,omitempty" gorm:"foreignKey:SeasonID;constraint:OnDelete:CASCADE"`
+}
+
+func (Season) TableName() string { return "seasons" }
+
+// SeasonStanding — строка турнирной таблицы сезона; для закрытого сезона — замороженный снимок.
+type SeasonStanding struct {
+	ID          uint    `json:"-" gorm:"primaryKey"`
+	SeasonID    uint    `json:"-" gorm:"not null;index"`
+	Rank        int     `json:"rank"`
+	UserID      uint    `json:"user_id"`
+	PlayerName  string  `json:"player_name" gorm:"size:100"`
+	GamesCount  int     `json:"games_count"`
+	WinsCount   int     `json:"wins_count"`
+	LossesCount int     `json:"losses_count"`
+	WinRate     float64 `json:"win_rate"`
+	Points      int     `json:"points"`
+}
+
+func (SeasonStanding) TableName() string { return "season_standings" }
+
+// SeasonRequest — создание/обновление сезона; points_win и points_loss необязательны (по умолчанию 3 и 0).
+type SeasonRequest struct {
+	Name       string `json:"name" binding:"required,min=2,max=100"`
+	StartDate  string `json:"start_date" binding:"required"`
+	EndDate    string `json:"end_date" binding:"required"`
+	PointsWin  *int   `json:"points_win"`
+	PointsLoss *int   `json:"points_loss"`
+}
+
+// SeasonStandingsResponse — таблица сезона; frozen — снимок закрытого сезона, иначе расчёт по текущим играм.
+type SeasonStandingsResponse struct {
+	Season    Season           `json:"season"`
+	Frozen    bool             `json:"frozen"`
+	Standings []SeasonStanding `json:"standings"`
+}
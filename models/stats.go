@@ -0,0 +1,307 @@
+package models
+
+import "time"
+
+// HeadToHeadPlayer — участник сравнения «один на один».
+type HeadToHeadPlayer struct {
+	UserID     uint   `json:"user_id"`
+	PlayerName string `json:"player_name"`
+}
+
+// HeadToHeadDeck — колода игрока в партиях против второго игрока.
+type HeadToHeadDeck struct {
+	DeckID     int     `json:"deck_id"`
+	DeckName   string  `json:"deck_name"`
+	GamesCount int     `json:"games_count"`
+	WinsCount  int     `json:"wins_count"`
+	WinRate    float64 `json:"win_rate"`
+}
+
+// HeadToHeadStreak — текущая серия побед одного игрока над другим подряд.
+type HeadToHeadStreak struct {
+	UserID uint `json:"user_id"`
+	Length int  `json:"length"`
+}
+
+// HeadToHeadOpponents — партии, где игроки были в разных командах.
+type HeadToHeadOpponents struct {
+	GamesCount    int               `json:"games_count"`
+	UserAWins     int               `json:"user_a_wins"`
+	UserBWins     int               `json:"user_b_wins"`
+	UserAWinRate  float64           `json:"user_a_win_rate"`
+	UserBWinRate  float64           `json:"user_b_win_rate"`
+	UserADecks    []HeadToHeadDeck  `json:"user_a_decks"`
+	UserBDecks    []HeadToHeadDeck  `json:"user_b_decks"`
+	CurrentStreak *HeadToHeadStreak `json:"current_streak,omitempty"`
+}
+
+// HeadToHeadTeammates — партии, где игроки были в одной команде.
+type HeadToHeadTeammates struct {
+	GamesCount int     `json:"games_count"`
+	WinsCount  int     `json:"wins_count"`
+	WinRate    float64 `json:"win_rate"`
+}
+
+// HeadToHeadMeeting — одна совместная партия; relation — opponents|teammates.
+type HeadToHeadMeeting struct {
+	GameID        uint      `json:"game_id"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Relation      string    `json:"relation"`
+	UserATeam     int       `json:"user_a_team"`
+	UserBTeam     int       `json:"user_b_team"`
+	WinningTeam   int       `json:"winning_team"`
+	UserAWon      bool      `json:"user_a_won"`
+	UserBWon      bool      `json:"user_b_won"`
+	UserADeckID   int       `json:"user_a_deck_id"`
+	UserADeckName string    `json:"user_a_deck_name"`
+	UserBDeckID   int       `json:"user_b_deck_id"`
+	UserBDeckName string    `json:"user_b_deck_name"`
+}
+
+// HeadToHeadResponse — ответ /api/stats/head-to-head.
+type HeadToHeadResponse struct {
+	UserA          HeadToHeadPlayer    `json:"user_a"`
+	UserB          HeadToHeadPlayer    `json:"user_b"`
+	FromDate       string              `json:"from_date,omitempty"`
+	ToDate         string              `json:"to_date,omitempty"`
+	GamesTogether  int                 `json:"games_together"`
+	Opponents      HeadToHeadOpponents `json:"opponents"`
+	Teammates      HeadToHeadTeammates `json:"teammates"`
+	RecentMeetings []HeadToHeadMeeting `json:"recent_meetings"`
+}
+
+// TeammatePairStats — пара игроков из одной команды: совместные игры и отличие от личного процента побед.
+type TeammatePairStats struct {
+	Player1ID           uint    `json:"player1_id"`
+	Player1Name         string  `json:"player1_name"`
+	Player2ID           uint    `json:"player2_id"`
+	Player2Name         string  `json:"player2_name"`
+	GamesCount          int     `json:"games_count"`
+	WinsCount           int     `json:"wins_count"`
+	WinRate             float64 `json:"win_rate"`
+	Player1WinRate      float64 `json:"player1_win_rate"`
+	Player2WinRate      float64 `json:"player2_win_rate"`
+	Player1WinRateDelta float64 `json:"player1_win_rate_delta"`
+	Player2WinRateDelta float64 `json:"player2_win_rate_delta"`
+}
+
+// TeammatesResponse — ответ /api/stats/teammates.
+type TeammatesResponse struct {
+	UserID   *uint               `json:"user_id,omitempty"`
+	Sort     string              `json:"sort"`
+	MinGames int                 `json:"min_games"`
+	Pairs    []TeammatePairStats `json:"pairs"`
+}
+
+// DeckPair — две колоды одной команды (deck1_id <= deck2_id).
+type DeckPair struct {
+	Deck1ID   int    `json:"deck1_id"`
+	Deck1Name string `json:"deck1_name"`
+	Deck2ID   int    `json:"deck2_id"`
+	Deck2Name string `json:"deck2_name"`
+}
+
+// DeckPairStats — результаты пары колод, сыгранных в одной команде.
+type DeckPairStats struct {
+	DeckPair
+	GamesCount int     `json:"games_count"`
+	WinsCount  int     `json:"wins_count"`
+	WinRate    float64 `json:"win_rate"`
+}
+
+// DeckPairMatchupStats — матчап составов 2v2: пара колод против пары колод.
+type DeckPairMatchupStats struct {
+	PairA        DeckPair `json:"pair_a"`
+	PairB        DeckPair `json:"pair_b"`
+	GamesCount   int      `json:"games_count"`
+	PairAWins    int      `json:"pair_a_wins"`
+	PairBWins    int      `json:"pair_b_wins"`
+	PairAWinRate float64  `json:"pair_a_win_rate"`
+	PairBWinRate float64  `json:"pair_b_win_rate"`
+}
+
+// DeckPairsResponse — ответ /api/stats/deck-pairs.
+type DeckPairsResponse struct {
+	MinGames int                    `json:"min_games"`
+	Pairs    []DeckPairStats        `json:"pairs"`
+	Matchups []DeckPairMatchupStats `json:"matchups"`
+}
+
+// PlayerDeckStats — ячейка матрицы «игрок × колода».
+type PlayerDeckStats struct {
+	UserID             uint      `json:"user_id"`
+	PlayerName         string    `json:"player_name"`
+	DeckID             int       `json:"deck_id"`
+	DeckName           string    `json:"deck_name"`
+	GamesCount         int       `json:"games_count"`
+	WinsCount          int       `json:"wins_count"`
+	WinRate            float64   `json:"win_rate"`
+	AvgTurnDurationSec int       `json:"avg_turn_duration_sec"`
+	LastPlayedAt       time.Time `json:"last_played_at"`
+}
+
+// WinRateConfidence — 95% интервал Уилсона для процента побед и байесовская оценка,
+// сглаженная к 50% (малые выборки не выглядят как 0% или 100%). Все значения в процентах.
+type WinRateConfidence struct {
+	WinRateLow      float64 `json:"win_rate_low"`
+	WinRateHigh     float64 `json:"win_rate_high"`
+	BayesianWinRate float64 `json:"bayesian_win_rate"`
+}
+
+// TurnHistogramBucket — корзина гистограммы длительности ходов [from_sec, to_sec); to_sec == nil — без верхней границы.
+type TurnHistogramBucket struct {
+	FromSec int  `json:"from_sec"`
+	ToSec   *int `json:"to_sec,omitempty"`
+	Count   int  `json:"count"`
+}
+
+// TurnDistribution — распределение длительности ходов и овертайм.
+type TurnDistribution struct {
+	TurnsCount       int                   `json:"turns_count"`
+	AvgSec           float64               `json:"avg_sec"`
+	MedianSec        float64               `json:"median_sec"`
+	P75Sec           float64               `json:"p75_sec"`
+	P90Sec           float64               `json:"p90_sec"`
+	P95Sec           float64               `json:"p95_sec"`
+	MaxSec           int                   `json:"max_sec"`
+	OvertimeTurns    int                   `json:"overtime_turns"`
+	OvertimeRate     float64               `json:"overtime_rate"`
+	TotalOvertimeSec int                   `json:"total_overtime_sec"`
+	Histogram        []TurnHistogramBucket `json:"histogram"`
+}
+
+// PlayerTurnStats — ходы команды игрока в его партиях.
+type PlayerTurnStats struct {
+	UserID     uint   `json:"user_id"`
+	PlayerName string `json:"player_name"`
+	GamesCount int    `json:"games_count"`
+	TurnDistribution
+}
+
+// DeckTurnStats — ходы команды, в которой играла колода.
+type DeckTurnStats struct {
+	DeckID     int    `json:"deck_id"`
+	DeckName   string `json:"deck_name"`
+	GamesCount int    `json:"games_count"`
+	TurnDistribution
+}
+
+// TeamTurnStats — ходы команды по номеру (1 — первая половина игроков).
+type TeamTurnStats struct {
+	TeamNumber int `json:"team_number"`
+	TurnDistribution
+}
+
+// TurnTrendPoint — скорость ходов за период (group_by: day|week|month).
+type TurnTrendPoint struct {
+	Period       string  `json:"period"`
+	TurnsCount   int     `json:"turns_count"`
+	AvgSec       float64 `json:"avg_sec"`
+	MedianSec    float64 `json:"median_sec"`
+	OvertimeRate float64 `json:"overtime_rate"`
+}
+
+// TurnWinCorrelation — связь скорости ходов с победой по парам «команда × игра».
+// faster_team_win_rate — без партий с равным средним ходом; correlation — точечно-бисериальная корреляция
+// среднего хода команды с победой (отрицательная — быстрые выигрывают чаще).
+type TurnWinCorrelation struct {
+	GamesCompared     int     `json:"games_compared"`
+	WinnersAvgSec     float64 `json:"winners_avg_sec"`
+	LosersAvgSec      float64 `json:"losers_avg_sec"`
+	FasterTeamWins    int     `json:"faster_team_wins"`
+	FasterTeamWinRate float64 `json:"faster_team_win_rate"`
+	Correlation       float64 `json:"correlation"`
+}
+
+// TurnStatsResponse — ответ /api/stats/turns.
+type TurnStatsResponse struct {
+	GroupBy   string             `json:"group_by"`
+	Timezone  string             `json:"timezone"`
+	Overall   TurnDistribution   `json:"overall"`
+	Players   []PlayerTurnStats  `json:"players"`
+	Decks     []DeckTurnStats    `json:"decks"`
+	Teams     []TeamTurnStats    `json:"teams"`
+	Trend     []TurnTrendPoint   `json:"trend"`
+	WinImpact TurnWinCorrelation `json:"win_impact"`
+}
+
+// GameLengthSummary — длительность партий (без пауз) и число ходов.
+type GameLengthSummary struct {
+	GamesCount        int     `json:"games_count"`
+	AvgDurationSec    float64 `json:"avg_duration_sec"`
+	MedianDurationSec float64 `json:"median_duration_sec"`
+	P25DurationSec    float64 `json:"p25_duration_sec"`
+	P75DurationSec    float64 `json:"p75_duration_sec"`
+	P90DurationSec    float64 `json:"p90_duration_sec"`
+	MinDurationSec    int     `json:"min_duration_sec"`
+	MaxDurationSec    int     `json:"max_duration_sec"`
+	AvgTurns          float64 `json:"avg_turns"`
+	MedianTurns       float64 `json:"median_turns"`
+}
+
+// DeckGameLength — длительность партий с участием колоды.
+type DeckGameLength struct {
+	DeckID   int    `json:"deck_id"`
+	DeckName string `json:"deck_name"`
+	GameLengthSummary
+}
+
+// MatchupGameLength — длительность партий матчапа колод (deck1_id <= deck2_id, как в deck-matchups).
+type MatchupGameLength struct {
+	Deck1ID   int    `json:"deck1_id"`
+	Deck1Name string `json:"deck1_name"`
+	Deck2ID   int    `json:"deck2_id"`
+	Deck2Name string `json:"deck2_name"`
+	GameLengthSummary
+}
+
+// PlayerCountGameLength — длительность партий по числу игроков.
+type PlayerCountGameLength struct {
+	PlayersCount int `json:"players_count"`
+	GameLengthSummary
+}
+
+// PeriodGameLength — длительность партий за период (group_by: day|week|month).
+type PeriodGameLength struct {
+	Period string `json:"period"`
+	GameLengthSummary
+}
+
+// GameLengthEntry — одна партия в списках самых быстрых и самых долгих.
+type GameLengthEntry struct {
+	GameID       uint      `json:"game_id"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	DurationSec  int       `json:"duration_sec"`
+	TurnsCount   int       `json:"turns_count"`
+	PlayersCount int       `json:"players_count"`
+	DeckNames    []string  `json:"deck_names"`
+}
+
+// GameLengthResponse — ответ /api/stats/game-length.
+type GameLengthResponse struct {
+	GroupBy       string                  `json:"group_by"`
+	Timezone      string                  `json:"timezone"`
+	Overall       GameLengthSummary       `json:"overall"`
+	ByDeck        []DeckGameLength        `json:"by_deck"`
+	ByMatchup     []MatchupGameLength     `json:"by_matchup"`
+	ByPlayerCount []PlayerCountGameLength `json:"by_player_count"`
+	ByPeriod      []PeriodGameLength      `json:"by_period"`
+	Fastest       []GameLengthEntry       `json:"fastest"`
+	Slowest       []GameLengthEntry       `json:"slowest"`
+}
+
+// ActiveGameEstimate — прогноз оставшегося времени активной партии (ответ /api/stats/game-length/active).
+// Ожидаемая длительность — медиана завершённых партий с тем же числом игроков, которые длились дольше уже прошедшего;
+// sample_size — размер этой выборки, при 0 прогноза нет.
+type ActiveGameEstimate struct {
+	GameID               uint     `json:"game_id"`
+	PlayersCount         int      `json:"players_count"`
+	ElapsedSec           int      `json:"elapsed_sec"`
+	TurnsCount           int      `json:"turns_count"`
+	SampleSize           int      `json:"sample_size"`
+	ExpectedTotalSec     *float64 `json:"expected_total_sec,omitempty"`
+	ExpectedRemainingSec *float64 `json:"expected_remaining_sec,omitempty"`
+	ExpectedTotalTurns   *float64 `json:"expected_total_turns,omitempty"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// AppSettingStatsAggregatesKey — отметка (RFC3339) последней полной пересборки агрегатов статистики.
+// Пока её нет, статистика читается живыми запросами.
+const AppSettingStatsAggregatesKey = "stats_aggregates_built_at"
+
+// StatsPlayerAggregate — накопленная статистика игрока по завершённым играм, включая серии.
+type StatsPlayerAggregate struct {
+	UserID             uint       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	GamesCount         int        `json:"games_count"`
+	WinsCount          int        `json:"wins_count"`
+	FirstMoveGames     int        `json:"first_move_games"`
+	FirstMoveWins      int        `json:"first_move_wins"`
+	TurnsCount         int        `json:"turns_count"`
+	TurnsDurationSec   int64      `json:"turns_duration_sec"`
+	MaxTurnDurationSec int        `json:"max_turn_duration_sec"`
+	CurrentWinStreak   int        `json:"current_win_streak"`
+	CurrentLossStreak  int        `json:"current_loss_streak"`
+	MaxWinStreak       int        `json:"max_win_streak"`
+	MaxLossStreak      int        `json:"max_loss_streak"`
+	LastGameAt         *time.Time `json:"last_game_at,omitempty"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+func (StatsPlayerAggregate) TableName() string { return "stats_players" }
+
+// StatsPlayerDeckAggregate — накопленная статистика игрока на колоде.
+type StatsPlayerDeckAggregate struct {
+	UserID           uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	DeckID           int       `json:"deck_id" gorm:"primaryKey;autoIncrement:false"`
+	DeckName         string    `json:"deck_name"`
+	GamesCount       int       `json:"games_count"`
+	WinsCount        int       `json:"wins_count"`
+	TurnsCount       int       `json:"turns_count"`
+	TurnsDurationSec int64     `json:"turns_duration_sec"`
+	LastPlayedAt     time.Time `json:"last_played_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (StatsPlayerDeckAggregate) TableName() string { return "stats_player_decks" }
+
+// StatsDeckAggregate — накопленная статистика колоды.
+type StatsDeckAggregate struct {
+	DeckID     int       `json:"deck_id" gorm:"primaryKey;autoIncrement:false"`
+	DeckName   string    `json:"deck_name"`
+	GamesCount int       `json:"games_count"`
+	WinsCount  int       `json:"wins_count"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (StatsDeckAggregate) TableName() string { return "stats_decks" }
+
+// StatsDeckMatchupAggregate — накопленный матчап колод (deck1_id <= deck2_id, как в GetDeckMatchups).
+type StatsDeckMatchupAggregate struct {
+	Deck1ID    int       `json:"deck1_id" gorm:"primaryKey;autoIncrement:false"`
+	Deck2ID    int       `json:"deck2_id" gorm:"primaryKey;autoIncrement:false"`
+	Deck1Name  string    `json:"deck1_name"`
+	Deck2Name  string    `json:"deck2_name"`
+	GamesCount int       `json:"games_count"`
+	Deck1Wins  int       `json:"deck1_wins"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (StatsDeckMatchupAggregate) TableName() string { return "stats_deck_matchups" }
+
+// StatsPeriodAggregate — число игр за период мета-дашборда (group_by: day|week|month).
+type StatsPeriodAggregate struct {
+	GroupBy    string    `json:"group_by" gorm:"primaryKey;size:10"`
+	Period     string    `json:"period" gorm:"primaryKey;size:16"`
+	TotalGames int       `json:"total_games"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (StatsPeriodAggregate) TableName() string { return "stats_periods" }
+
+// StatsPeriodDeckAggregate — игры и победы колоды за период мета-дашборда.
+type StatsPeriodDeckAggregate struct {
+	GroupBy    string    `json:"group_by" gorm:"primaryKey;size:10"`
+	Period     string    `json:"period" gorm:"primaryKey;size:16"`
+	DeckID     int       `json:"deck_id" gorm:"primaryKey;autoIncrement:false"`
+	DeckName   string    `json:"deck_name"`
+	GamesCount int       `json:"games_count"`
+	WinsCount  int       `json:"wins_count"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (StatsPeriodDeckAggregate) TableName() string { return "stats_period_decks" }
@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// Статусы турнира.
+const (
+	TournamentStatusRegistration = "registration"
+	TournamentStatusSwiss        = "swiss"
+	TournamentStatusTop          = "top"
+	TournamentStatusFinished     = "finished"
+)
+
+// Стадии матча турнира.
+const (
+	TournamentStageSwiss = "swiss"
+	TournamentStageTop   = "top"
+)
+
+// Tournament — турнир: швейцарские раунды (swiss_rounds, 0 — по числу игроков) и необязательный
+// топ-кат на выбывание (top_cut: 0, 2, 4, 8…). best_of — партий до победы в матче (1 или 3).
+type Tournament struct {
+	ID             uint               `json:"id" gorm:"primaryKey"`
+	Name           string             `json:"name" gorm:"size:100;not null"`
+	Status         string             `json:"status" gorm:"size:20;not null"`
+	BestOf         int                `json:"best_of" gorm:"not null"`
+	SwissRounds    int                `json:"swiss_rounds"`
+	TopCut         int                `json:"top_cut"`
+	CurrentRound   int                `json:"current_round"`
+	ChampionUserID *uint              `json:"champion_user_id,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Players        []TournamentPlayer `json:"players,omitempty" gorm:"foreignKey:TournamentID;constraint:OnDelete:CASCADE"`
+	Matches        []TournamentMatch  `json:"matches,omitempty" gorm:"foreignKey:TournamentID;constraint:OnDelete:CASCADE"`
+}
+
+func (Tournament) TableName() string { return "tournaments" }
+
+// TournamentPlayer — зарегистрированный участник турнира со своей колодой.
+type TournamentPlayer struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	TournamentID uint   `json:"-" gorm:"not null;uniqueIndex:idx_tournament_player"`
+	UserID       uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_tournament_player"`
+	PlayerName   string `json:"player_name" gorm:"size:100"`
+	DeckID       int    `json:"deck_id"`
+	DeckName     string `json:"deck_name" gorm:"size:150"`
+}
+
+func (TournamentPlayer) TableName() string { return "tournament_players" }
+
+// TournamentMatch — пара раунда; player2_id == nil — бай (автоматическая победа player1).
+// game_id — последняя партия матча в приложении; счёт по партиям — player1_wins/player2_wins.
+type TournamentMatch struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	TournamentID uint      `json:"-" gorm:"not null;index"`
+	Round        int       `json:"round"`
+	Stage        string    `json:"stage" gorm:"size:10;not null"`
+	TableNumber  int       `json:"table_number"`
+	Player1ID    uint      `json:"player1_id"`
+	Player2ID    *uint     `json:"player2_id,omitempty"`
+	Player1Wins  int       `json:"player1_wins"`
+	Player2Wins  int       `json:"player2_wins"`
+	WinnerID     *uint     `json:"winner_id,omitempty"`
+	GameID       *uint     `json:"game_id,omitempty" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (TournamentMatch) TableName() string { return "tournament_matches" }
+
+// TournamentRequest — создание турнира; best_of по умолчанию 1.
+type TournamentRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	BestOf      int    `json:"best_of"`
+	SwissRounds int    `json:"swiss_rounds"`
+	TopCut      int    `json:"top_cut"`
+}
+
+// TournamentPlayerRequest — регистрация участника с колодой.
+type TournamentPlayerRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+	DeckID int  `json:"deck_id" binding:"required"`
+}
+
+// TournamentMatchResultRequest — счёт матча, сыгранного вне приложения (на бумаге).
+type TournamentMatchResultRequest struct {
+	Player1Wins int `json:"player1_wins"`
+	Player2Wins int `json:"player2_wins"`
+}
+
+// TournamentStanding — строка таблицы швейцарской части: очки (3 за победу в матче, бай — победа)
+// и тай-брейки OMW% (средний процент побед соперников в матчах), GW% (процент побед в партиях), OGW%.
+type TournamentStanding struct {
+	Rank         int     `json:"rank"`
+	UserID       uint    `json:"user_id"`
+	PlayerName   string  `json:"player_name"`
+	DeckName     string  `json:"deck_name"`
+	MatchPoints  int     `json:"match_points"`
+	MatchWins    int     `json:"match_wins"`
+	MatchLosses  int     `json:"match_losses"`
+	Byes         int     `json:"byes"`
+	MatchWinRate float64 `json:"match_win_rate"`
+	OMWRate      float64 `json:"omw_rate"`
+	GameWinRate  float64 `json:"game_win_rate"`
+	OGWRate      float64 `json:"ogw_rate"`
+}
+
+// TournamentResponse — турнир с участниками, матчами и текущей таблицей.
+type TournamentResponse struct {
+	Tournament
+	Standings []TournamentStanding `json:"standings"`
+}
+
+// TournamentMatchGameRequest — необязательные лимиты времени партии матча (как в CreateGameRequest).
+type TournamentMatchGameRequest struct {
+	TurnLimitSeconds     int `json:"turn_limit_seconds"`
+	TeamTimeLimitSeconds int `json:"team_time_limit_seconds"`
+}
@@ -1,16 +1,32 @@
--- Удаление игры и всех связанных данных (game_turns, game_players).
+-- Удаление игры и всех связанных данных (game_pauses, game_turns, game_players, player_rating_history,
+-- user_achievements); матч турнира, ссылающийся на игру, отвязывается (счёт матча сохраняется).
 --
 -- Запуск: psql $DATABASE_URL -v game_id=42 -f delete_game.sql
 -- (замените 42 на нужный ID игры)
 --
+-- Рейтинги player_ratings обновляются инкрементально и продолжают учитывать удалённую игру.
+-- После удаления обязательно выполните полный пересчёт: POST /api/stats/ratings/recompute (админ).
+--
+-- Достижения, открытые этой игрой, удаляются; другие могли зависеть от неё (серии, счётчики побед) —
+-- обязательно выполните POST /api/achievements/recompute (админ).
+--
+-- Агрегаты статистики (stats_*) тоже продолжают учитывать игру: скрипт снимает отметку их сборки,
+-- поэтому до пересборки статистика читается живыми запросами, а сервер пересоберёт агрегаты при следующем старте.
+-- Пересобрать сразу: go run ./cmd/rebuildstats или POST /api/stats/rebuild (админ).
+--
 -- После удаления ID новых игр продолжают sequence (например, 1,2,5,100 -> новая игра получит 101).
 -- Чтобы перенумеровать игры в 1,2,3... и сбросить sequence, выполните:
 --   psql $DATABASE_URL -f scripts/renumber_game_ids.sql
 
 BEGIN;
 
+DELETE FROM player_rating_history WHERE game_id = :game_id;
+DELETE FROM user_achievements WHERE game_id = :game_id;
+UPDATE tournament_matches SET game_id = NULL WHERE game_id = :game_id;
+DELETE FROM game_pauses  WHERE game_id = :game_id;
 DELETE FROM game_turns   WHERE game_id = :game_id;
 DELETE FROM game_players WHERE game_id = :game_id;
 DELETE FROM games       WHERE id       = :game_id;
+DELETE FROM app_settings WHERE key = 'stats_aggregates_built_at';
 
 COMMIT;
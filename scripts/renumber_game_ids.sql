@@ -1,5 +1,6 @@
 -- Перенумерация id партий (games): 16, 17, 18... -> 1, 2, 3...
--- Обновляет games, game_players и game_turns для согласованности.
+-- Обновляет games, game_players, game_turns и game_pauses для согласованности,
+-- а также ссылки на игры без FK: player_rating_history, user_achievements и tournament_matches.
 -- Выполнять в транзакции (откат при ошибке).
 
 BEGIN;
@@ -27,6 +28,7 @@ UPDATE games SET id = id + 10000;
 -- 3. Обновляем ссылки в дочерних таблицах на смещённые id
 UPDATE game_players gp SET game_id = gp.game_id + 10000;
 UPDATE game_turns gt SET game_id = gt.game_id + 10000;
+UPDATE game_pauses gpz SET game_id = gpz.game_id + 10000;
 
 -- 4. Переназначаем id в games на 1, 2, 3...
 UPDATE games g SET id = m.new_id
@@ -39,14 +41,28 @@ FROM game_id_map m WHERE gp.game_id = m.old_id + 10000;
 UPDATE game_turns gt SET game_id = m.new_id
 FROM game_id_map m WHERE gt.game_id = m.old_id + 10000;
 
+UPDATE game_pauses gpz SET game_id = m.new_id
+FROM game_id_map m WHERE gpz.game_id = m.old_id + 10000;
+
+-- 5a. Ссылки на игры без FK (сдвига не было — меняем старый id на новый)
+UPDATE player_rating_history h SET game_id = m.new_id
+FROM game_id_map m WHERE h.game_id = m.old_id;
+
+UPDATE user_achievements ua SET game_id = m.new_id
+FROM game_id_map m WHERE ua.game_id = m.old_id;
+
+UPDATE tournament_matches tm SET game_id = m.new_id
+FROM game_id_map m WHERE tm.game_id = m.old_id;
+
 -- 6. Сбрасываем sequence для games.id (чтобы новые записи получали id > max)
 SELECT setval(
   pg_get_serial_sequence('games', 'id'),
   COALESCE((SELECT MAX(id) FROM games), 1)
 );
 
--- 7. Восстанавливаем FK-ограничения (game_players и game_turns ссылаются на games.id)
+-- 7. Восстанавливаем FK-ограничения (game_players, game_turns и game_pauses ссылаются на games.id)
 ALTER TABLE game_players ADD CONSTRAINT fk_games_players FOREIGN KEY (game_id) REFERENCES games(id);
 ALTER TABLE game_turns ADD CONSTRAINT fk_game_turns_game_id FOREIGN KEY (game_id) REFERENCES games(id);
+ALTER TABLE game_pauses ADD CONSTRAINT fk_games_pauses FOREIGN KEY (game_id) REFERENCES games(id);
 
 COMMIT;